@@ -0,0 +1,241 @@
+// Command azapi converts Microsoft's Azure resource tag support table into the table embedded by the providers package.
+// For more information check: https://github.com/env0/terratag/issues/209
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	resourceTypeColumn     = "resource type"
+	supportsTagsColumn     = "supports tags"
+	tagsInCostReportColumn = "tag in cost report"
+)
+
+type tagSupport struct {
+	resourceType     string
+	supportsTags     bool
+	tagsInCostReport bool
+}
+
+func main() {
+	input := flag.String("input", "", "a local copy of Microsoft's tag support table, markdown (tag-support.md) or CSV. Nothing is generated when empty")
+	output := flag.String("output", "azure_resource_tag_support.csv", "the generated CSV file")
+
+	flag.Parse()
+
+	// go generate runs with an empty -input when AZURE_TAG_SUPPORT is unset.
+	if *input == "" {
+		log.Print("[INFO] No -input table (the markdown source of https://learn.microsoft.com/en-us/azure/azure-resource-manager/management/tag-support, or a CSV export of it), skipping")
+
+		return
+	}
+
+	if err := generate(*input, *output); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(input string, output string) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var resources []tagSupport
+
+	if strings.EqualFold(filepath.Ext(input), ".csv") {
+		resources, err = parseCsv(in)
+	} else {
+		resources, err = parseMarkdown(in)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", input, err)
+	}
+
+	if len(resources) == 0 {
+		return fmt.Errorf("no resource types found in %s", input)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return writeCsv(out, resources)
+}
+
+// parseMarkdown parses the tables of the tag support article, one per resource provider namespace (the preceding "## " heading):
+//
+//	## Microsoft.AAD
+//
+//	> | Resource type | Supports tags | Tag in cost report |
+//	> | ------------- | ----------- | ----------- |
+//	> | domainServices | Yes | Yes |
+func parseMarkdown(r io.Reader) ([]tagSupport, error) {
+	var resources []tagSupport
+
+	var namespace string
+
+	var columns map[string]int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(scanner.Text()), ">"))
+
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			namespace = normalizeResourceType(heading)
+			columns = nil
+
+			continue
+		}
+
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+
+		if columns == nil {
+			columns = getColumns(cells)
+
+			continue
+		}
+
+		if strings.Trim(strings.Join(cells, ""), " -:") == "" {
+			// The header separator row.
+			continue
+		}
+
+		resource, err := newTagSupport(cells, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		if namespace != "" {
+			resource.resourceType = namespace + "/" + resource.resourceType
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, scanner.Err()
+}
+
+// parseCsv parses a CSV with a header row. The resource types must include their namespace (e.g. Microsoft.AAD/domainServices).
+func parseCsv(r io.Reader) ([]tagSupport, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := getColumns(records[0])
+
+	resources := make([]tagSupport, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		resource, err := newTagSupport(record, columns)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+func getColumns(header []string) map[string]int {
+	columns := map[string]int{}
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		// Both "Tag in cost report" and "Tags in cost report" are used.
+		name = strings.Replace(name, "tags in", "tag in", 1)
+		columns[name] = i
+	}
+
+	return columns
+}
+
+func newTagSupport(cells []string, columns map[string]int) (tagSupport, error) {
+	for _, column := range []string{resourceTypeColumn, supportsTagsColumn, tagsInCostReportColumn} {
+		if i, ok := columns[column]; !ok || i >= len(cells) {
+			return tagSupport{}, fmt.Errorf("missing %q column in %q", column, strings.Join(cells, "|"))
+		}
+	}
+
+	resourceType := normalizeResourceType(cells[columns[resourceTypeColumn]])
+	if resourceType == "" {
+		return tagSupport{}, errors.New("empty resource type")
+	}
+
+	return tagSupport{
+		resourceType:     resourceType,
+		supportsTags:     isYes(cells[columns[supportsTagsColumn]]),
+		tagsInCostReport: isYes(cells[columns[tagsInCostReportColumn]]),
+	}, nil
+}
+
+// normalizeResourceType lowercases the resource type and removes its spaces and markdown formatting (e.g. "domainServices / oucontainer").
+func normalizeResourceType(resourceType string) string {
+	resourceType = strings.ToLower(resourceType)
+
+	return strings.NewReplacer(" ", "", "\t", "", "`", "", "*", "", `\`, "").Replace(resourceType)
+}
+
+// isYes checks if a cell is "Yes", possibly followed by notes (e.g. "Yes, except...").
+func isYes(cell string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(cell)), "yes")
+}
+
+func writeCsv(w io.Writer, resources []tagSupport) error {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].resourceType < resources[j].resourceType
+	})
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"Resource type", "Supports tags", "Tag in cost report"}); err != nil {
+		return err
+	}
+
+	for i, resource := range resources {
+		if i > 0 && resource.resourceType == resources[i-1].resourceType {
+			continue
+		}
+
+		if err := writer.Write([]string{resource.resourceType, yesNo(resource.supportsTags), yesNo(resource.tagsInCostReport)}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+
+	return "No"
+}
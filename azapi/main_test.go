@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	resources, err := parseMarkdown(strings.NewReader(`# Tag support for Azure resources
+
+## Microsoft.AAD
+
+> [!div class="mx-tableFixed"]
+> | Resource type | Supports tags | Tag in cost report |
+> | ------------- | ----------- | ----------- |
+> | domainServices | Yes | Yes |
+> | domainServices / oucontainer | No | No |
+
+## Microsoft.Network
+
+| Resource type | Supports tags | Tag in cost report |
+| ------------- | ----------- | ----------- |
+| dnszones | Yes, except for the records | No |
+
+## Next steps
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []tagSupport{
+		{resourceType: "microsoft.aad/domainservices", supportsTags: true, tagsInCostReport: true},
+		{resourceType: "microsoft.aad/domainservices/oucontainer"},
+		{resourceType: "microsoft.network/dnszones", supportsTags: true},
+	}, resources)
+}
+
+func TestParseCsv(t *testing.T) {
+	resources, err := parseCsv(strings.NewReader(`Resource type,Supports tags,Tags in cost report
+Microsoft.Storage/storageAccounts,Yes,Yes
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []tagSupport{
+		{resourceType: "microsoft.storage/storageaccounts", supportsTags: true, tagsInCostReport: true},
+	}, resources)
+
+	_, err = parseCsv(strings.NewReader("Resource type\nMicrosoft.Storage/storageAccounts\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteCsv(t *testing.T) {
+	var b bytes.Buffer
+
+	require.NoError(t, writeCsv(&b, []tagSupport{
+		{resourceType: "microsoft.network/dnszones", supportsTags: true},
+		{resourceType: "microsoft.aad/domainservices", supportsTags: true, tagsInCostReport: true},
+		{resourceType: "microsoft.network/dnszones", supportsTags: true},
+	}))
+	assert.Equal(t, `Resource type,Supports tags,Tag in cost report
+microsoft.aad/domainservices,Yes,Yes
+microsoft.network/dnszones,Yes,No
+`, b.String())
+}
@@ -12,6 +12,10 @@ import (
 
 type Args struct {
 	Tags                string
+	TagsFile            string
+	Config              string
+	Normalize           bool
+	Strategy            string
 	Dir                 string
 	Filter              string
 	Skip                string
@@ -22,10 +26,28 @@ type Args struct {
 	Version             bool
 	DefaultToTerraform  bool
 	KeepExistingTags    bool
+	DryRun              bool
+	Check               bool
+	Revert              bool
+	Report              string
+	ReportFile          string
+	SchemaFile          string
+	SchemaCacheDir      string
+	NoSchemaCache       bool
+	TagNestedBlocks     bool
+}
+
+// SchemaExportArgs are the arguments of the 'schema export' command.
+type SchemaExportArgs struct {
+	Dir                string
+	Type               string
+	DefaultToTerraform bool
+	Out                string
+	Verbose            bool
 }
 
 func validate(args Args) error {
-	if args.Tags == "" {
+	if args.Tags == "" && args.TagsFile == "" && !args.Revert {
 		return errors.New("missing tags")
 	}
 
@@ -33,6 +55,19 @@ func validate(args Args) error {
 		return fmt.Errorf("invalid type %s, must be either 'terraform', 'terragrunt', or 'terragrunt-run-all'", args.Type)
 	}
 
+	if args.Strategy != string(common.ResourcesStrategy) && args.Strategy != string(common.ProviderDefaultsStrategy) {
+		return fmt.Errorf("invalid strategy %s, must be either 'resources' or 'provider-defaults'", args.Strategy)
+	}
+
+	if args.Report != "" && args.Report != "json" {
+		return fmt.Errorf("invalid report format %s, must be 'json'", args.Report)
+	}
+
+	// The diffs and the check results are printed to stdout too.
+	if args.Report != "" && args.ReportFile == "" && (args.DryRun || args.Check) {
+		return errors.New("-report-file is required when -report is used with -dry-run or -check")
+	}
+
 	return nil
 }
 
@@ -44,6 +79,11 @@ func InitArgs() (Args, error) {
 	fs := flag.NewFlagSet(programName, flag.ExitOnError)
 
 	fs.StringVar(&args.Tags, "tags", "", "Tags as a valid JSON document")
+	fs.StringVar(&args.TagsFile, "tags-file", "", "A JSON, YAML or HCL (tags = {...}) file with tags. Merged with -tags (which takes precedence)")
+	fs.StringVar(&args.Config, "config", "", "A YAML or JSON config file (e.g. additional tags per provider and resource type)")
+	fs.BoolVar(&args.Normalize, "normalize", false, "Transform tags that are invalid for a provider (e.g. uppercase GCP labels) instead of failing")
+	fs.StringVar(&args.Strategy, "strategy", string(common.ResourcesStrategy), "The tagging strategy. Valid values: resources (tag every resource), or provider-defaults (tag the provider blocks default tags when supported)")
+	fs.BoolVar(&args.TagNestedBlocks, "tag-nested-blocks", false, "Also tag the existing nested blocks that have a tags/labels map attribute in the provider schema")
 	fs.StringVar(&args.Dir, "dir", ".", "Directory to recursively search for .tf files and terratag them")
 	fs.BoolVar(&args.IsSkipTerratagFiles, "skipTerratagFiles", true, "Skips any previously tagged files")
 	fs.StringVar(&args.Filter, "filter", ".*", "Only apply tags to the selected resource types (regex)")
@@ -54,21 +94,17 @@ func InitArgs() (Args, error) {
 	fs.BoolVar(&args.Version, "version", false, "Prints the version")
 	fs.BoolVar(&args.DefaultToTerraform, "default-to-terraform", false, "By default uses OpenTofu (if installed), if set will use Terraform even when Opentofu is installed")
 	fs.BoolVar(&args.KeepExistingTags, "keep-existing-tags", false, "When set, existing tags will be preserved when merging tags (by default, new tags override existing ones)")
+	fs.BoolVar(&args.Revert, "revert", false, "Revert previous terratag runs: restore the original files from their backups (or remove the terratag changes if there are no backups)")
+	fs.BoolVar(&args.Check, "check", false, "Don't modify any file. Fail and list every taggable resource that is not tagged yet")
+	fs.StringVar(&args.Report, "report", "", "Emit a run report in the given format. Valid values: json")
+	fs.StringVar(&args.ReportFile, "report-file", "", "Write the run report to a file instead of stdout (defaults to a json report)")
+	fs.BoolVar(&args.DryRun, "dry-run", false, "Print a unified diff of the changes to stdout instead of writing any file")
+	fs.StringVar(&args.SchemaFile, "schema-file", "", "Load the provider schemas from a 'providers schema -json' file (or a directory of *.json files) instead of running terraform/tofu/terragrunt")
 
-	// Set cli args based on environment variables.
-	// The command line flags have precedence over environment variables.
-	fs.VisitAll(func(f *flag.Flag) {
-		if f.Name == "version" {
-			return
-		}
+	fs.StringVar(&args.SchemaCacheDir, "schema-cache-dir", "", "The provider schemas cache directory (defaults to <user cache dir>/terratag/schemas)")
+	fs.BoolVar(&args.NoSchemaCache, "no-schema-cache", false, "Don't use the provider schemas cache (always run 'providers schema -json')")
 
-		name := "TERRATAG_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
-		if value, ok := os.LookupEnv(name); ok {
-			if err := fs.Set(f.Name, value); err != nil {
-				fmt.Printf("[WARN] failed to set command arg flag '%s' from environment variable '%s': %v\n", f.Name, name, err)
-			}
-		}
-	})
+	setFlagsFromEnv(fs)
 
 	if err := fs.Parse(programArgs); err != nil {
 		return args, err
@@ -78,9 +114,55 @@ func InitArgs() (Args, error) {
 		return args, nil
 	}
 
+	if args.ReportFile != "" && args.Report == "" {
+		args.Report = "json"
+	}
+
 	if err := validate(args); err != nil {
 		return args, err
 	}
 
 	return args, nil
 }
+
+// InitSchemaExportArgs parses the arguments of the 'schema export' command.
+func InitSchemaExportArgs(programArgs []string) (SchemaExportArgs, error) {
+	args := SchemaExportArgs{}
+
+	fs := flag.NewFlagSet("schema export", flag.ExitOnError)
+
+	fs.StringVar(&args.Dir, "dir", ".", "The (initialized) directory to export the provider schemas of")
+	fs.StringVar(&args.Type, "type", string(common.Terraform), "The IAC type. Valid values: terraform, terragrunt, or terragrunt-run-all")
+	fs.BoolVar(&args.DefaultToTerraform, "default-to-terraform", false, "By default uses OpenTofu (if installed), if set will use Terraform even when Opentofu is installed")
+	fs.StringVar(&args.Out, "out", "", "Write the provider schemas to a file instead of stdout")
+	fs.BoolVar(&args.Verbose, "verbose", false, "Enable verbose logging")
+
+	setFlagsFromEnv(fs)
+
+	if err := fs.Parse(programArgs); err != nil {
+		return args, err
+	}
+
+	if args.Type != string(common.Terraform) && args.Type != string(common.Terragrunt) && args.Type != string(common.TerragruntRunAll) {
+		return args, fmt.Errorf("invalid type %s, must be either 'terraform', 'terragrunt', or 'terragrunt-run-all'", args.Type)
+	}
+
+	return args, nil
+}
+
+// setFlagsFromEnv sets cli args based on environment variables (TERRATAG_<FLAG>).
+// The command line flags have precedence over environment variables.
+func setFlagsFromEnv(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "version" {
+			return
+		}
+
+		name := "TERRATAG_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				fmt.Printf("[WARN] failed to set command arg flag '%s' from environment variable '%s': %v\n", f.Name, name, err)
+			}
+		}
+	})
+}
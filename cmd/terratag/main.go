@@ -15,6 +15,12 @@ import (
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		schemaCommand(os.Args[2:])
+
+		return
+	}
+
 	args, err := cli.InitArgs()
 	if err != nil {
 		fmt.Println(err)
@@ -39,6 +45,28 @@ func main() {
 
 	if err := terratag.Terratag(args); err != nil {
 		log.Printf("[ERROR] execution failed due to an error\n%v", err)
+		os.Exit(1)
+	}
+}
+
+func schemaCommand(programArgs []string) {
+	if len(programArgs) == 0 || programArgs[0] != "export" {
+		fmt.Println("Usage: terratag schema export [-dir=\".\"] [-out=\"schema.json\"]")
+		os.Exit(1)
+	}
+
+	args, err := cli.InitSchemaExportArgs(programArgs[1:])
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Usage: terratag schema export [-dir=\".\"] [-out=\"schema.json\"]")
+		os.Exit(1)
+	}
+
+	initLogFiltering(args.Verbose)
+
+	if err := terratag.ExportSchema(args); err != nil {
+		log.Printf("[ERROR] execution failed due to an error\n%v", err)
+		os.Exit(1)
 	}
 }
 
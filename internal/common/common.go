@@ -1,6 +1,9 @@
 package common
 
-import "github.com/hashicorp/hcl/v2/hclwrite"
+import (
+	"github.com/env0/terratag/internal/config"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
 
 type IACType string
 
@@ -10,6 +13,19 @@ const (
 	TerragruntRunAll IACType = "terragrunt-run-all"
 )
 
+type TaggingStrategy string
+
+const (
+	// ResourcesStrategy tags every resource.
+	ResourcesStrategy TaggingStrategy = "resources"
+	// ProviderDefaultsStrategy tags the provider blocks default tags (when supported by the provider),
+	// and only the resources that do not honor them.
+	ProviderDefaultsStrategy TaggingStrategy = "provider-defaults"
+)
+
+// ExpressionPrefix marks tag values that are HCL expressions (e.g. "expr:var.owner") rather than strings.
+const ExpressionPrefix = "expr:"
+
 type Version struct {
 	Major int
 	Minor int
@@ -26,9 +42,17 @@ type TaggingArgs struct {
 	DefaultToTerraform  bool
 	IACType             IACType
 	KeepExistingTags    bool
+	DryRun              bool
+	Check               bool
+	Config              *config.Config
+	Normalize           bool
+	Strategy            TaggingStrategy
+	// TagNestedBlocks - also tag the nested blocks with a tags/labels map attribute (based on the provider schema).
+	TagNestedBlocks bool
+	// DefaultTagsProviders are the names of the provider blocks tagged in the provider-defaults strategy (e.g. aws, aws.west, google).
+	DefaultTagsProviders map[string]bool
 }
 
 type TerratagLocal struct {
 	Found map[string]hclwrite.Tokens
-	Added string
 }
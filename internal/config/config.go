@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tag set names are joined by tagSetSeparator, so they may not contain it (nor start or end with an underscore).
+var tagSetNameRegex = regexp.MustCompile(`^[a-zA-Z0-9]+(_[a-zA-Z0-9]+)*$`)
+
+const tagSetSeparator = "__"
+
+// ResourceTags are additional (or overriding) tags for the resource types matching the regex.
+type ResourceTags struct {
+	// Name is used in the generated locals name. Defaults to rule<index>.
+	Name  string            `yaml:"name"`
+	Type  string            `yaml:"type"`
+	Tags  map[string]string `yaml:"tags"`
+	regex *regexp.Regexp
+}
+
+// OCI sets which attribute of the OCI resources is tagged.
+type OCI struct {
+	// TagAttribute is either freeform_tags (the default) or defined_tags.
+	TagAttribute string `yaml:"tag_attribute"`
+	// TagNamespace is the namespace of the defined tags (required for defined_tags).
+	TagNamespace string `yaml:"tag_namespace"`
+}
+
+// Kubernetes sets which labels of the kubernetes resources are tagged (in addition to metadata.labels).
+type Kubernetes struct {
+	// TagPodTemplates also tags the metadata.labels of the workloads pod templates (e.g. spec.template).
+	TagPodTemplates bool `yaml:"tag_pod_templates"`
+}
+
+// Provider is a user-defined provider: how to tag the resources with a type prefix.
+type Provider struct {
+	Prefix string `yaml:"prefix"`
+	// Name is the provider name (e.g. for provider_tags). Defaults to the prefix without its trailing underscore.
+	Name string `yaml:"name"`
+	// TagAttribute is the tags attribute, or a path of nested blocks ending with the attribute (e.g. metadata.labels).
+	TagAttribute string `yaml:"tag_attribute"`
+	// Format is the shape of the tags attribute: map, key_value_strings (a list of "key:value" strings)
+	// or key_value_list (a list of {key, value} objects). Detected from the provider schema by default.
+	Format string `yaml:"format"`
+}
+
+var providerFormats = []string{"", "map", "key_value_strings", "key_value_list"}
+
+var tagAttributeRegex = regexp.MustCompile(`^[a-zA-Z_][\w-]*(\.[a-zA-Z_][\w-]*)*$`)
+
+// Config is the terratag configuration file (YAML or JSON).
+type Config struct {
+	// ProviderTags are additional (or overriding) tags per provider (aws, gcp, azure, ...).
+	ProviderTags map[string]map[string]string `yaml:"provider_tags"`
+	// ResourceTags are applied in order (tags of later rules override earlier ones).
+	ResourceTags []*ResourceTags `yaml:"resource_tags"`
+	OCI          *OCI            `yaml:"oci"`
+	Kubernetes   *Kubernetes     `yaml:"kubernetes"`
+	Providers    []*Provider     `yaml:"providers"`
+}
+
+func Load(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+
+	// YAML is a superset of JSON.
+	if err := yaml.Unmarshal(src, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for i, resourceTags := range config.ResourceTags {
+		if resourceTags.Type == "" {
+			return nil, fmt.Errorf("resource_tags[%d]: missing type", i)
+		}
+
+		if resourceTags.Name == "" {
+			resourceTags.Name = "rule" + strconv.Itoa(i+1)
+		}
+
+		if !tagSetNameRegex.MatchString(resourceTags.Name) {
+			return nil, fmt.Errorf("resource_tags[%d]: invalid name %s, may only contain letters, digits and single underscores between them", i, resourceTags.Name)
+		}
+
+		if resourceTags.regex, err = regexp.Compile(resourceTags.Type); err != nil {
+			return nil, fmt.Errorf("resource_tags[%d]: invalid type regex: %w", i, err)
+		}
+	}
+
+	for provider := range config.ProviderTags {
+		if !tagSetNameRegex.MatchString(provider) {
+			return nil, errors.New("invalid provider name " + provider)
+		}
+	}
+
+	for i, provider := range config.Providers {
+		if provider.Prefix == "" {
+			return nil, fmt.Errorf("providers[%d]: missing prefix", i)
+		}
+
+		if provider.Name == "" {
+			provider.Name = strings.TrimSuffix(provider.Prefix, "_")
+		}
+
+		if !tagSetNameRegex.MatchString(provider.Name) {
+			return nil, fmt.Errorf("providers[%d]: invalid name %s, may only contain letters, digits and single underscores between them", i, provider.Name)
+		}
+
+		if !tagAttributeRegex.MatchString(provider.TagAttribute) {
+			return nil, fmt.Errorf("providers[%d]: invalid tag_attribute %q", i, provider.TagAttribute)
+		}
+
+		if !slices.Contains(providerFormats, provider.Format) {
+			return nil, fmt.Errorf("providers[%d]: invalid format %s, must be either 'map', 'key_value_strings' or 'key_value_list'", i, provider.Format)
+		}
+	}
+
+	if config.OCI != nil {
+		switch config.OCI.TagAttribute {
+		case "", "freeform_tags":
+			if config.OCI.TagNamespace != "" {
+				return nil, errors.New("oci: tag_namespace is only supported with the defined_tags tag_attribute")
+			}
+		case "defined_tags":
+			if config.OCI.TagNamespace == "" {
+				return nil, errors.New("oci: missing tag_namespace (required for the defined_tags tag_attribute)")
+			}
+		default:
+			return nil, fmt.Errorf("oci: invalid tag_attribute %s, must be either 'freeform_tags' or 'defined_tags'", config.OCI.TagAttribute)
+		}
+	}
+
+	return config, nil
+}
+
+// GetOCITagAttribute returns the tagged attribute of OCI resources and the namespace of the tags (for defined tags).
+func (c *Config) GetOCITagAttribute() (string, string) {
+	if c == nil || c.OCI == nil || c.OCI.TagAttribute == "" {
+		return "freeform_tags", ""
+	}
+
+	return c.OCI.TagAttribute, c.OCI.TagNamespace
+}
+
+// IsTaggingPodTemplates checks if the pod templates of the kubernetes workloads are tagged.
+func (c *Config) IsTaggingPodTemplates() bool {
+	return c != nil && c.Kubernetes != nil && c.Kubernetes.TagPodTemplates
+}
+
+// GetTagSet returns the additional tags of a resource, and a name identifying this set of tags: the provider
+// followed by the names of the matching rules (e.g. aws__compute), so the tag sets of different providers never share a name.
+// An empty name is returned if there are no additional tags.
+func (c *Config) GetTagSet(provider string, resourceType string) (string, map[string]string) {
+	if c == nil {
+		return "", nil
+	}
+
+	names := []string{provider}
+	tags := map[string]string{}
+
+	providerTags, hasProviderTags := c.ProviderTags[provider]
+	maps.Copy(tags, providerTags)
+
+	for _, resourceTags := range c.ResourceTags {
+		if !resourceTags.regex.MatchString(resourceType) {
+			continue
+		}
+
+		names = append(names, resourceTags.Name)
+		maps.Copy(tags, resourceTags.Tags)
+	}
+
+	if !hasProviderTags && len(names) == 1 {
+		return "", tags
+	}
+
+	return strings.Join(names, tagSetSeparator), tags
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTagSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+provider_tags:
+  aws:
+    cost-center: aws
+resource_tags:
+  - name: compute
+    type: ^aws_instance$
+    tags:
+      cost-center: compute
+      backup: daily
+  - type: _bucket$
+    tags:
+      public: "false"
+`), 0644))
+
+	config, err := Load(path)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		provider     string
+		resourceType string
+		expectedName string
+		expectedTags map[string]string
+	}{
+		{"aws", "aws_vpc", "aws", map[string]string{"cost-center": "aws"}},
+		{"aws", "aws_instance", "aws__compute", map[string]string{"cost-center": "compute", "backup": "daily"}},
+		{"gcp", "google_storage_bucket", "gcp__rule2", map[string]string{"public": "false"}},
+		{"gcp", "google_compute_instance", "", map[string]string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.resourceType, func(t *testing.T) {
+			name, tags := config.GetTagSet(tc.provider, tc.resourceType)
+			assert.Equal(t, tc.expectedName, name)
+			assert.Equal(t, tc.expectedTags, tags)
+		})
+	}
+}
+
+func TestGetTagSet_UniqueNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+provider_tags:
+  aws:
+    cost-center: aws
+resource_tags:
+  - name: compute
+    type: ^aws_instance$
+  - name: aws_compute
+    type: ^google_compute_instance$
+`), 0644))
+
+	config, err := Load(path)
+	require.NoError(t, err)
+
+	awsName, _ := config.GetTagSet("aws", "aws_instance")
+	gcpName, _ := config.GetTagSet("gcp", "google_compute_instance")
+	assert.NotEqual(t, awsName, gcpName)
+
+	// A rule named after a provider.
+	require.NoError(t, os.WriteFile(path, []byte(`
+provider_tags:
+  aws:
+    cost-center: aws
+resource_tags:
+  - name: aws
+    type: ^google_
+`), 0644))
+
+	config, err = Load(path)
+	require.NoError(t, err)
+
+	awsName, _ = config.GetTagSet("aws", "aws_vpc")
+	gcpName, _ = config.GetTagSet("gcp", "google_storage_bucket")
+	assert.Equal(t, "aws", awsName)
+	assert.Equal(t, "gcp__aws", gcpName)
+
+	for _, name := range []string{"aws__compute", "_compute", "compute_"} {
+		require.NoError(t, os.WriteFile(path, []byte("resource_tags:\n  - name: "+name+"\n    type: ^aws_instance$\n"), 0644))
+
+		_, err := Load(path)
+		assert.ErrorContains(t, err, "invalid name", name)
+	}
+}
+
+func TestOCI(t *testing.T) {
+	testCases := []struct {
+		name              string
+		config            string
+		expectedAttribute string
+		expectedNamespace string
+		expectedError     string
+	}{
+		{name: "Default", config: `{}`, expectedAttribute: "freeform_tags"},
+		{name: "Freeform tags", config: "oci:\n  tag_attribute: freeform_tags", expectedAttribute: "freeform_tags"},
+		{name: "Defined tags", config: "oci:\n  tag_attribute: defined_tags\n  tag_namespace: Operations", expectedAttribute: "defined_tags", expectedNamespace: "Operations"},
+		{name: "Defined tags without namespace", config: "oci:\n  tag_attribute: defined_tags", expectedError: "missing tag_namespace"},
+		{name: "Freeform tags with namespace", config: "oci:\n  tag_namespace: Operations", expectedError: "only supported with the defined_tags"},
+		{name: "Invalid attribute", config: "oci:\n  tag_attribute: tags", expectedError: "invalid tag_attribute"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0644))
+
+			config, err := Load(path)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			attribute, namespace := config.GetOCITagAttribute()
+			assert.Equal(t, tc.expectedAttribute, attribute)
+			assert.Equal(t, tc.expectedNamespace, namespace)
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        string
+		expected      *Provider
+		expectedError string
+	}{
+		{name: "Default name", config: "providers:\n  - prefix: datadog_\n    tag_attribute: tags\n    format: key_value_strings", expected: &Provider{Prefix: "datadog_", Name: "datadog", TagAttribute: "tags", Format: "key_value_strings"}},
+		{name: "Nested attribute", config: "providers:\n  - prefix: kubernetes_\n    name: k8s\n    tag_attribute: metadata.labels", expected: &Provider{Prefix: "kubernetes_", Name: "k8s", TagAttribute: "metadata.labels"}},
+		{name: "Missing prefix", config: "providers:\n  - tag_attribute: tags", expectedError: "missing prefix"},
+		{name: "Invalid tag attribute", config: "providers:\n  - prefix: datadog_\n    tag_attribute: tags.", expectedError: "invalid tag_attribute"},
+		{name: "Invalid format", config: "providers:\n  - prefix: datadog_\n    tag_attribute: tags\n    format: list", expectedError: "invalid format"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0644))
+
+			config, err := Load(path)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []*Provider{tc.expected}, config.Providers)
+		})
+	}
+}
+
+func TestKubernetes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("kubernetes:\n  tag_pod_templates: true"), 0644))
+
+	config, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, config.IsTaggingPodTemplates())
+
+	config = nil
+	assert.False(t, config.IsTaggingPodTemplates())
+}
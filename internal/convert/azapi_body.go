@@ -0,0 +1,133 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The tags of azapi resources may be set in their body (an object or jsonencode of an object):
+//
+//	body = {
+//	  properties = { ... }
+//	  tags       = merge({ "Name" = "b" }, local.terratag_added_main)
+//	}
+
+// bodyTags is the "tags" item of an azapi resource body.
+type bodyTags struct {
+	src []byte
+	// start and end are the byte offsets of the tags value (both are the offset of the object closing brace if there are no tags).
+	start, end int
+	found      bool
+	// itemStart is the byte offset of the tags key.
+	itemStart int
+}
+
+func getBodyTags(block *hclwrite.Block) (*bodyTags, bool) {
+	attribute := block.Body().GetAttribute("body")
+	if attribute == nil {
+		return nil, false
+	}
+
+	src := attribute.Expr().BuildTokens(nil).Bytes()
+
+	expr, diags := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "jsonencode" && len(call.Args) == 1 {
+		expr = call.Args[0]
+	}
+
+	object, ok := expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, false
+	}
+
+	for _, item := range object.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || !key.IsKnown() || key.IsNull() || !key.Type().Equals(cty.String) || key.AsString() != "tags" {
+			continue
+		}
+
+		return &bodyTags{
+			src:       src,
+			start:     item.ValueExpr.Range().Start.Byte,
+			end:       item.ValueExpr.Range().End.Byte,
+			found:     true,
+			itemStart: item.KeyExpr.Range().Start.Byte,
+		}, true
+	}
+
+	closingBrace := object.SrcRange.End.Byte - 1
+
+	return &bodyTags{src: src, start: closingBrace, end: closingBrace}, true
+}
+
+// HasBodyTags checks if an azapi resource body object has tags.
+func HasBodyTags(block *hclwrite.Block) bool {
+	tags, ok := getBodyTags(block)
+
+	return ok && tags.found
+}
+
+// SetBodyTags sets the tags of an azapi resource body object to the value returned by tagsValue, given the existing
+// tags expression (empty if there are none). Returns false if the body isn't an object.
+func SetBodyTags(block *hclwrite.Block, tagsValue func(existing string) string) bool {
+	tags, ok := getBodyTags(block)
+	if !ok {
+		return false
+	}
+
+	var body string
+
+	if tags.found {
+		body = string(tags.src[:tags.start]) + tagsValue(string(tags.src[tags.start:tags.end])) + string(tags.src[tags.end:])
+	} else {
+		// Add the tags as the last item of the object.
+		prefix := strings.TrimRight(string(tags.src[:tags.start]), " \t")
+		if !strings.HasSuffix(prefix, "\n") {
+			prefix += "\n"
+		}
+
+		body = prefix + "tags = " + tagsValue("") + "\n" + string(tags.src[tags.start:])
+	}
+
+	block.Body().SetAttributeRaw("body", parseExpressionTokens(body))
+
+	return true
+}
+
+// removeBodyTags removes the terratag locals from the tags of an azapi resource body (see SetBodyTags).
+func removeBodyTags(block *hclwrite.Block) bool {
+	tags, ok := getBodyTags(block)
+	if !ok || !tags.found {
+		return false
+	}
+
+	stripped, changed := stripTerratagReferences(parseExpressionTokens(string(tags.src[tags.start:tags.end])))
+	if !changed {
+		return false
+	}
+
+	var body string
+
+	if len(stripped) > 0 {
+		body = string(tags.src[:tags.start]) + strings.TrimSpace(string(stripped.Bytes())) + string(tags.src[tags.end:])
+	} else {
+		// Remove the tags item line.
+		prefix := strings.TrimRight(string(tags.src[:tags.itemStart]), " \t")
+		suffix := strings.TrimLeft(string(tags.src[tags.end:]), " \t")
+		suffix = strings.TrimPrefix(strings.TrimPrefix(suffix, ","), "\n")
+
+		body = prefix + suffix
+	}
+
+	block.Body().SetAttributeRaw("body", parseExpressionTokens(body))
+
+	return true
+}
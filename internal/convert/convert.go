@@ -3,18 +3,63 @@ package convert
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 
 	"github.com/env0/terratag/internal/common"
-	"github.com/env0/terratag/internal/tag_keys"
 	"github.com/env0/terratag/internal/utils"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/thoas/go-funk"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// terratagAddedComment marks the blocks and attributes terratag adds (other than tags), so they are removed when reverting.
+const terratagAddedComment = "# Added by terratag"
+
+// AppendTerratagAddedComment marks the next attribute appended to the body as added by terratag
+// (the whole block, if called before setting any of its attributes).
+func AppendTerratagAddedComment(body *hclwrite.Body) {
+	body.AppendUnstructuredTokens(hclwrite.Tokens{{Type: hclsyntax.TokenComment, Bytes: []byte(terratagAddedComment + "\n")}})
+}
+
+// isTerratagAdded checks if the tokens (of a block body or an attribute) contain the terratag added comment.
+func isTerratagAdded(tokens hclwrite.Tokens) bool {
+	for _, token := range tokens {
+		if token.Type == hclsyntax.TokenComment && strings.TrimSpace(string(token.Bytes)) == terratagAddedComment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetTagValueExpression returns the HCL expression of a tag value:
+// the expression itself for "expr:" values, or a quoted (and escaped) string literal.
+func GetTagValueExpression(value string) (string, error) {
+	expression, ok := strings.CutPrefix(value, common.ExpressionPrefix)
+	if !ok {
+		return string(hclwrite.TokensForValue(cty.StringVal(value)).Bytes()), nil
+	}
+
+	expression = strings.TrimSpace(expression)
+
+	if _, diagnostics := hclsyntax.ParseExpression([]byte(expression), "", hcl.InitialPos); diagnostics.HasErrors() {
+		return "", fmt.Errorf("invalid tag value expression %s: %s", expression, diagnostics.Error())
+	}
+
+	return expression, nil
+}
+
+// parseExpressionTokens returns the tokens of an (already validated) HCL expression.
+func parseExpressionTokens(expression string) hclwrite.Tokens {
+	file, _ := hclwrite.ParseConfig([]byte("value = "+expression), "", hcl.InitialPos)
+
+	return file.Body().GetAttribute("value").Expr().BuildTokens(hclwrite.Tokens{})
+}
+
 func GetExistingTagsExpression(tokens hclwrite.Tokens) string {
 	return stringifyExpression(tokens)
 }
@@ -34,31 +79,40 @@ func stringifyExpression(tokens hclwrite.Tokens) string {
 	return expression
 }
 
-func AppendLocalsBlock(file *hclwrite.File, filename string, terratag common.TerratagLocal) {
-	key := tag_keys.GetTerratagAddedKey(filename)
+// AppendLocalsBlock sets the terratag locals (locals name to tags map).
+// Existing locals are replaced, the others are added in a new locals block.
+func AppendLocalsBlock(file *hclwrite.File, added map[string]string) {
+	var locals *hclwrite.Block
 
 	// If there's an existings terratag locals replace it with the merged locals.
-	blocks := file.Body().Blocks()
-	for _, block := range blocks {
-		if block.Type() != "locals" {
-			continue
+	for _, key := range utils.SortObjectKeys(added) {
+		found := false
+
+		for _, block := range file.Body().Blocks() {
+			if block.Type() != "locals" || block.Body().GetAttribute(key) == nil {
+				continue
+			}
+
+			block.Body().RemoveAttribute(key)
+			block.Body().SetAttributeValue(key, cty.StringVal(added[key]))
+
+			found = true
+
+			break
 		}
 
-		if block.Body().GetAttribute(key) == nil {
+		if found {
 			continue
 		}
 
-		block.Body().RemoveAttribute(key)
-		block.Body().SetAttributeValue(key, cty.StringVal(terratag.Added))
+		if locals == nil {
+			file.Body().AppendNewline()
+			locals = file.Body().AppendNewBlock("locals", nil)
+			file.Body().AppendNewline()
+		}
 
-		return
+		locals.Body().SetAttributeValue(key, cty.StringVal(added[key]))
 	}
-
-	file.Body().AppendNewline()
-	locals := file.Body().AppendNewBlock("locals", nil)
-	file.Body().AppendNewline()
-
-	locals.Body().SetAttributeValue(key, cty.StringVal(terratag.Added))
 }
 
 func AppendTagBlocks(resource *hclwrite.Block, tags string) error {
@@ -72,8 +126,13 @@ func AppendTagBlocks(resource *hclwrite.Block, tags string) error {
 	for _, key := range keys {
 		resource.Body().AppendNewline()
 		tagBlock := resource.Body().AppendNewBlock("tag", nil)
+		value, err := GetTagValueExpression(tagsMap[key])
+		if err != nil {
+			return err
+		}
+
 		tagBlock.Body().SetAttributeValue("key", cty.StringVal(key))
-		tagBlock.Body().SetAttributeValue("value", cty.StringVal(tagsMap[key]))
+		tagBlock.Body().SetAttributeRaw("value", parseExpressionTokens(value))
 		tagBlock.Body().SetAttributeValue("propagate_at_launch", cty.BoolVal(true))
 	}
 
@@ -82,15 +141,12 @@ func AppendTagBlocks(resource *hclwrite.Block, tags string) error {
 
 func UnquoteTagsAttribute(swappedTagsStrings []string, text string) string {
 	for _, swappedTagString := range swappedTagsStrings {
-		// treat quotes
-		escapedByWriter := strings.ReplaceAll(swappedTagString, "\"", "\\\"")
-
-		// treat variables
-		escapedByWriter = strings.ReplaceAll(escapedByWriter, "${", "$${")
+		// escape (quotes, backslashes, variables, etc...) the same way the writer does
+		escapedByWriter := string(hclwrite.TokensForValue(cty.StringVal(swappedTagString)).Bytes())
 
-		// add quotes if string isn't wrapped with ${}
-		if !(strings.HasPrefix(swappedTagString, "${") && strings.HasSuffix(swappedTagString, "}")) {
-			escapedByWriter = "\"" + escapedByWriter + "\""
+		// remove quotes if string is wrapped with ${}
+		if strings.HasPrefix(swappedTagString, "${") && strings.HasSuffix(swappedTagString, "}") {
+			escapedByWriter = strings.TrimSuffix(strings.TrimPrefix(escapedByWriter, "\""), "\"")
 		}
 
 		text = strings.ReplaceAll(text, escapedByWriter, swappedTagString)
@@ -99,7 +155,8 @@ func UnquoteTagsAttribute(swappedTagsStrings []string, text string) string {
 	return text
 }
 
-func MoveExistingTags(filename string, terratag common.TerratagLocal, block *hclwrite.Block, tagId string) (bool, error) {
+// MoveExistingTags moves the existing tags (attribute or block) of the block to terratag.Found[existingTagsKey].
+func MoveExistingTags(existingTagsKey string, terratag common.TerratagLocal, block *hclwrite.Block, tagId string) (bool, error) {
 	var existingTags hclwrite.Tokens
 
 	// First we try to find tags as attribute
@@ -125,7 +182,7 @@ func MoveExistingTags(filename string, terratag common.TerratagLocal, block *hcl
 	}
 
 	if existingTags != nil {
-		terratag.Found[tag_keys.GetResourceExistingTagsKey(filename, block)] = existingTags
+		terratag.Found[existingTagsKey] = existingTags
 
 		return true, nil
 	}
@@ -0,0 +1,101 @@
+package convert
+
+import (
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Tags attributes modeled as a list (or set) of {key, value} objects (e.g. awscc) are tagged with a "for" expression
+// converting the terratag locals map:
+//
+//	[for k, v in local.terratag_added_main : { key = k, value = v }]
+//
+// Existing tags are concatenated (the tags of the other list are filtered out to avoid duplicate keys):
+//
+//	concat([for tag in <existing> : tag if !contains(keys(local.terratag_added_main), tag.key)], <terratag list>)
+//	concat(<existing>, [for k, v in local.terratag_added_main : { key = k, value = v } if !contains([for tag in <existing> : tag.key], k)])
+//
+// Tags attributes modeled as a list of "key:value" strings (e.g. datadog) are tagged the same way, with
+// "${k}:${v}" elements and split(":", tag)[0] keys.
+
+// terratagKeyValueListRegex matches the (whitespace stripped) terratag list.
+var terratagKeyValueListRegex = regexp.MustCompile(`^\[fork,vinlocal\.terratag_added_\w+:(\{key=k,value=v\}|"\$\{k\}:\$\{v\}")(if.*)?\]$`)
+
+// existingKeyValueListFilterRegex matches the (whitespace stripped) filter of the existing tags.
+var existingKeyValueListFilterRegex = regexp.MustCompile(`^tagif!contains\(keys\(local\.terratag_added_\w+\),(tag\.key|split\(":",tag\)\[0\])\)$`)
+
+// GetKeyValueListExpression returns the terratag locals (a map) as a list of {key, value} objects.
+func GetKeyValueListExpression(local string) string {
+	return "[for k, v in " + local + " : { key = k, value = v }]"
+}
+
+// MergeKeyValueListExpression is the list of {key, value} objects equivalent of merge(existing, local).
+func MergeKeyValueListExpression(existing string, local string, keepExisting bool) string {
+	if keepExisting {
+		return "concat(" + existing + ", [for k, v in " + local + " : { key = k, value = v } if !contains([for tag in " + existing + " : tag.key], k)])"
+	}
+
+	return "concat([for tag in " + existing + " : tag if !contains(keys(" + local + "), tag.key)], " + GetKeyValueListExpression(local) + ")"
+}
+
+// GetKeyValueStringsExpression returns the terratag locals (a map) as a list of "key:value" strings.
+func GetKeyValueStringsExpression(local string) string {
+	return "[for k, v in " + local + " : \"${k}:${v}\"]"
+}
+
+// MergeKeyValueStringsExpression is the list of "key:value" strings equivalent of merge(existing, local).
+func MergeKeyValueStringsExpression(existing string, local string, keepExisting bool) string {
+	if keepExisting {
+		return "concat(" + existing + ", [for k, v in " + local + " : \"${k}:${v}\" if !contains([for tag in " + existing + " : split(\":\", tag)[0]], k)])"
+	}
+
+	return "concat([for tag in " + existing + " : tag if !contains(keys(" + local + "), split(\":\", tag)[0])], " + GetKeyValueStringsExpression(local) + ")"
+}
+
+func compactTokens(tokens hclwrite.Tokens) string {
+	compact := ""
+
+	for _, token := range tokens {
+		compact += string(token.Bytes)
+	}
+
+	return compact
+}
+
+func isTerratagKeyValueList(tokens hclwrite.Tokens) bool {
+	return terratagKeyValueListRegex.MatchString(compactTokens(trimTokens(tokens)))
+}
+
+// getFilteredExistingKeyValueList returns the existing tags of a filtered existing tags expression.
+func getFilteredExistingKeyValueList(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	tokens = trimTokens(tokens)
+
+	if len(tokens) < 6 || compactTokens(tokens[:4]) != "[fortagin" || tokens[len(tokens)-1].Type != hclsyntax.TokenCBrack {
+		return nil, false
+	}
+
+	// The existing tags end at the last top level colon (the filter has none).
+	colon := -1
+	depth := 0
+
+	for i, token := range tokens[4 : len(tokens)-1] {
+		switch token.Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		case hclsyntax.TokenColon:
+			if depth == 0 {
+				colon = 4 + i
+			}
+		}
+	}
+
+	if colon == -1 || !existingKeyValueListFilterRegex.MatchString(compactTokens(tokens[colon+1:len(tokens)-1])) {
+		return nil, false
+	}
+
+	return trimTokens(tokens[4:colon]), true
+}
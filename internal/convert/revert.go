@@ -0,0 +1,322 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/env0/terratag/internal/tag_keys"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// RemoveTerratagTags strips everything terratag added to a file: the terratag_added_* locals,
+// their references inside merge(...)/flatten([...]) expressions (in resources and provider default tags),
+// the generated autoscaling group "tag" blocks, launch template "tag_specifications" blocks, azapi body tags
+// and the other attributes marked as added by terratag (e.g. the ECS services propagate_tags).
+// Returns true if the file was modified.
+func RemoveTerratagTags(file *hclwrite.File) (bool, error) {
+	added, err := removeTerratagLocals(file)
+	if err != nil {
+		return false, err
+	}
+
+	modified := len(added) > 0
+
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "resource" && block.Type() != "provider" {
+			continue
+		}
+
+		if len(block.Labels()) > 0 && block.Labels()[0] == "aws_launch_template" && removeTagSpecificationsBlocks(block) {
+			modified = true
+		}
+
+		if removeTerratagAddedAttributes(block) {
+			modified = true
+		}
+
+		if len(block.Labels()) > 0 && strings.HasPrefix(block.Labels()[0], "azapi_") && removeBodyTags(block) {
+			modified = true
+		}
+
+		if removeTerratagReferences(block) {
+			modified = true
+		}
+
+		if len(block.Labels()) > 0 && block.Labels()[0] == "aws_autoscaling_group" && removeTagBlocks(block, added) {
+			modified = true
+		}
+	}
+
+	return modified, nil
+}
+
+// removeTerratagLocals removes the terratag_added_* locals (and any locals block left empty)
+// and returns the merged tags that were declared in them.
+func removeTerratagLocals(file *hclwrite.File) (Locals, error) {
+	added := Locals{}
+
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "locals" {
+			continue
+		}
+
+		for key, attribute := range block.Body().Attributes() {
+			if !tag_keys.IsTerratagAddedKey(key) {
+				continue
+			}
+
+			locals := Locals{}
+			if err := decodeTerratagLocals(locals, stringifyExpression(attribute.Expr().BuildTokens(hclwrite.Tokens{}))); err != nil {
+				return nil, err
+			}
+
+			for k, v := range locals {
+				added[k] = v
+			}
+
+			block.Body().RemoveAttribute(key)
+		}
+
+		if len(block.Body().Attributes()) == 0 && len(block.Body().Blocks()) == 0 {
+			file.Body().RemoveBlock(block)
+		}
+	}
+
+	return added, nil
+}
+
+// removeTerratagReferences recursively removes terratag locals references from the block attributes.
+// Nested blocks that are left empty (e.g. a generated "root_block_device") are removed.
+func removeTerratagReferences(block *hclwrite.Block) bool {
+	modified := false
+
+	for name, attribute := range block.Body().Attributes() {
+		tokens, changed := stripTerratagReferences(attribute.Expr().BuildTokens(hclwrite.Tokens{}))
+		if !changed {
+			continue
+		}
+
+		modified = true
+
+		if len(tokens) == 0 {
+			block.Body().RemoveAttribute(name)
+		} else {
+			block.Body().SetAttributeRaw(name, tokens)
+		}
+	}
+
+	for _, nestedBlock := range block.Body().Blocks() {
+		if removeTerratagReferences(nestedBlock) {
+			modified = true
+
+			if len(nestedBlock.Body().Attributes()) == 0 && len(nestedBlock.Body().Blocks()) == 0 {
+				block.Body().RemoveBlock(nestedBlock)
+			}
+		}
+	}
+
+	return modified
+}
+
+// stripTerratagReferences removes the terratag locals from an expression generated by terratag:
+//
+//	local.terratag_added_main                       => (removed)
+//	merge( <existing>, local.terratag_added_main)  => <existing>
+//	flatten([local.terratag_added_main,<existing>]) => <existing>
+//	concat(<existing>, [for k, v in local.terratag_added_main : ...]) => <existing> (see MergeKeyValueListExpression and MergeKeyValueStringsExpression)
+func stripTerratagReferences(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	tokens = trimTokens(tokens)
+
+	if isTerratagLocalsReference(tokens) {
+		return nil, true
+	}
+
+	var args []hclwrite.Tokens
+
+	if isTerratagKeyValueList(tokens) {
+		return nil, true
+	}
+
+	switch {
+	case isFunctionCall(tokens, "merge"), isFunctionCall(tokens, "concat"):
+		args = splitArguments(tokens[2 : len(tokens)-1])
+	case isFunctionCall(tokens, "flatten") && len(tokens) > 4 &&
+		tokens[2].Type == hclsyntax.TokenOBrack && tokens[len(tokens)-2].Type == hclsyntax.TokenCBrack:
+		args = splitArguments(tokens[3 : len(tokens)-2])
+	default:
+		return tokens, false
+	}
+
+	var remaining []hclwrite.Tokens
+
+	changed := false
+
+	for _, arg := range args {
+		if isTerratagLocalsReference(arg) || isTerratagKeyValueList(arg) {
+			changed = true
+		} else if existing, ok := getFilteredExistingKeyValueList(arg); ok {
+			changed = true
+
+			remaining = append(remaining, existing)
+		} else {
+			remaining = append(remaining, arg)
+		}
+	}
+
+	if !changed {
+		return tokens, false
+	}
+
+	switch len(remaining) {
+	case 0:
+		return nil, true
+	case 1:
+		// The existing tags may have been merged more than once (when re-tagging terratag files).
+		stripped, _ := stripTerratagReferences(remaining[0])
+
+		return stripped, true
+	}
+
+	stripped := hclwrite.Tokens{tokens[0], tokens[1]}
+
+	for i, arg := range remaining {
+		if i > 0 {
+			stripped = append(stripped, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+			arg[0].SpacesBefore = 1
+		}
+
+		stripped = append(stripped, arg...)
+	}
+
+	return append(stripped, tokens[len(tokens)-1]), true
+}
+
+func trimTokens(tokens hclwrite.Tokens) hclwrite.Tokens {
+	for len(tokens) > 0 && tokens[0].Type == hclsyntax.TokenNewline {
+		tokens = tokens[1:]
+	}
+
+	for len(tokens) > 0 && tokens[len(tokens)-1].Type == hclsyntax.TokenNewline {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	if len(tokens) > 0 {
+		tokens[0].SpacesBefore = 0
+	}
+
+	return tokens
+}
+
+func isFunctionCall(tokens hclwrite.Tokens, name string) bool {
+	return len(tokens) > 2 &&
+		tokens[0].Type == hclsyntax.TokenIdent && string(tokens[0].Bytes) == name &&
+		tokens[1].Type == hclsyntax.TokenOParen &&
+		tokens[len(tokens)-1].Type == hclsyntax.TokenCParen
+}
+
+func isTerratagLocalsReference(tokens hclwrite.Tokens) bool {
+	tokens = trimTokens(tokens)
+
+	return len(tokens) == 3 &&
+		tokens[0].Type == hclsyntax.TokenIdent && string(tokens[0].Bytes) == "local" &&
+		tokens[1].Type == hclsyntax.TokenDot &&
+		tokens[2].Type == hclsyntax.TokenIdent && tag_keys.IsTerratagAddedKey(string(tokens[2].Bytes))
+}
+
+// splitArguments splits a list of tokens by its top level commas.
+func splitArguments(tokens hclwrite.Tokens) []hclwrite.Tokens {
+	var args []hclwrite.Tokens
+
+	depth := 0
+	start := 0
+
+	for i, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		case hclsyntax.TokenComma:
+			if depth == 0 {
+				args = append(args, trimTokens(tokens[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	if last := trimTokens(tokens[start:]); len(last) > 0 {
+		args = append(args, last)
+	}
+
+	return args
+}
+
+// removeTagBlocks removes the "tag" blocks generated by AppendTagBlocks for the given tags.
+func removeTagBlocks(block *hclwrite.Block, added Locals) bool {
+	modified := false
+
+	for _, tagBlock := range block.Body().Blocks() {
+		if tagBlock.Type() != "tag" {
+			continue
+		}
+
+		key := getStringAttribute(tagBlock, "key")
+		value, ok := added[key]
+
+		if !ok || getAttributeExpression(tagBlock, "value") != value || getStringAttribute(tagBlock, "propagate_at_launch") != "true" {
+			continue
+		}
+
+		block.Body().RemoveBlock(tagBlock)
+
+		modified = true
+	}
+
+	return modified
+}
+
+// removeTagSpecificationsBlocks removes the "tag_specifications" blocks added by the launch template tagging
+// (marked by the terratag added comment).
+func removeTagSpecificationsBlocks(block *hclwrite.Block) bool {
+	modified := false
+
+	for _, tagSpecifications := range block.Body().Blocks() {
+		if tagSpecifications.Type() != "tag_specifications" || !isTerratagAdded(tagSpecifications.Body().BuildTokens(hclwrite.Tokens{})) {
+			continue
+		}
+
+		block.Body().RemoveBlock(tagSpecifications)
+
+		modified = true
+	}
+
+	return modified
+}
+
+// removeTerratagAddedAttributes removes the attributes of the block marked by the terratag added comment.
+func removeTerratagAddedAttributes(block *hclwrite.Block) bool {
+	modified := false
+
+	for name, attribute := range block.Body().Attributes() {
+		if isTerratagAdded(attribute.BuildTokens(hclwrite.Tokens{})) {
+			block.Body().RemoveAttribute(name)
+
+			modified = true
+		}
+	}
+
+	return modified
+}
+
+func getAttributeExpression(block *hclwrite.Block, name string) string {
+	attribute := block.Body().GetAttribute(name)
+	if attribute == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(attribute.Expr().BuildTokens(hclwrite.Tokens{}).Bytes()))
+}
+
+func getStringAttribute(block *hclwrite.Block, name string) string {
+	return strings.Trim(getAttributeExpression(block, name), "\"")
+}
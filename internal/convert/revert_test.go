@@ -0,0 +1,150 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveTerratagTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Tags added by terratag",
+			input: `resource "aws_s3_bucket" "b" {
+  tags = local.terratag_added_main
+}
+locals {
+  terratag_added_main = {"a"="b"}
+}
+`,
+			expected: `resource "aws_s3_bucket" "b" {
+}
+`,
+		},
+		{
+			name: "Merged with existing tags",
+			input: `resource "aws_s3_bucket" "b" {
+  tags = merge( { "Name" = "b" }, local.terratag_added_main)
+}
+resource "aws_s3_bucket" "c" {
+  tags = merge( local.terratag_added_main, var.tags)
+}
+locals {
+  other = 1
+  terratag_added_main = {"a"="b"}
+}
+`,
+			expected: `resource "aws_s3_bucket" "b" {
+  tags = { "Name" = "b" }
+}
+resource "aws_s3_bucket" "c" {
+  tags = var.tags
+}
+locals {
+  other = 1
+}
+`,
+		},
+		{
+			name: "Autoscaling group",
+			input: `resource "aws_autoscaling_group" "a" {
+  tags = flatten([local.terratag_added_main,var.tags])
+}
+resource "aws_autoscaling_group" "b" {
+  tag {
+    key                 = "a"
+    value               = "b"
+    propagate_at_launch = true
+  }
+}
+locals {
+  terratag_added_main = {"a"="b"}
+}
+`,
+			expected: `resource "aws_autoscaling_group" "a" {
+  tags = var.tags
+}
+resource "aws_autoscaling_group" "b" {
+}
+`,
+		},
+		{
+			name: "Autoscaling group dynamic tag",
+			input: `resource "aws_autoscaling_group" "a" {
+  dynamic "tag" {
+    for_each = merge(var.tags, local.terratag_added_main)
+    content {
+      key   = tag.key
+      value = tag.value
+    }
+  }
+}
+locals {
+  terratag_added_main = {"a"="b"}
+}
+`,
+			expected: `resource "aws_autoscaling_group" "a" {
+  dynamic "tag" {
+    for_each = var.tags
+    content {
+      key   = tag.key
+      value = tag.value
+    }
+  }
+}
+`,
+		},
+		{
+			name: "Launch template",
+			input: `resource "aws_launch_template" "lt" {
+  tags = local.terratag_added_main
+  tag_specifications {
+    resource_type = "instance"
+    tags          = merge( { "Name" = "i" }, local.terratag_added_main)
+  }
+  tag_specifications {
+    resource_type = "network-interface"
+    tags          = local.terratag_added_main
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "volume"
+    tags          = local.terratag_added_main
+  }
+}
+locals {
+  terratag_added_main = {"a"="b"}
+}
+`,
+			expected: `resource "aws_launch_template" "lt" {
+  tag_specifications {
+    resource_type = "instance"
+    tags          = { "Name" = "i" }
+  }
+  tag_specifications {
+    resource_type = "network-interface"
+  }
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, diags := hclwrite.ParseConfig([]byte(tc.input), "main.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors())
+
+			modified, err := RemoveTerratagTags(file)
+			require.NoError(t, err)
+			assert.True(t, modified)
+			assert.Equal(t, tc.expected, string(file.Bytes()))
+		})
+	}
+}
@@ -3,15 +3,16 @@ package convert
 import (
 	"errors"
 	"fmt"
-	"regexp"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
-var localsRegex = regexp.MustCompile(`"([^"]*)"[ \t]*=[ \t]*"([^"]*)"`)
-
+// Locals maps the (escaped, unquoted) keys to the HCL expressions of the values
+// (a quoted string literal, or any other expression such as var.owner).
 type Locals map[string]string
 
 func decodeTerratagLocals(locals Locals, s string) error {
@@ -19,13 +20,23 @@ func decodeTerratagLocals(locals Locals, s string) error {
 		delete(locals, k)
 	}
 
-	matches := localsRegex.FindAllStringSubmatch(s, -1)
-	if matches == nil {
-		return errors.New("no matches found when decoding locals")
+	src := []byte(s)
+
+	expression, diagnostics := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+	if diagnostics.HasErrors() {
+		return fmt.Errorf("failed to decode locals: %s", diagnostics.Error())
 	}
 
-	for _, match := range matches {
-		locals[match[1]] = match[2]
+	object, ok := expression.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return errors.New("failed to decode locals: not an object")
+	}
+
+	for _, item := range object.Items {
+		key := strings.TrimSpace(string(item.KeyExpr.Range().SliceBytes(src)))
+		key = strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\"")
+
+		locals[key] = strings.TrimSpace(string(item.ValueExpr.Range().SliceBytes(src)))
 	}
 
 	return nil
@@ -44,7 +55,7 @@ func encodeTerratagLocals(locals Locals) string {
 	sort.Strings(keys)
 
 	for _, key := range keys {
-		ret += fmt.Sprintf("\"%s\" = \"%s\", ", key, locals[key])
+		ret += fmt.Sprintf("\"%s\" = %s, ", key, locals[key])
 	}
 
 	ret = strings.TrimSuffix(ret, ", ")
@@ -55,8 +66,7 @@ func encodeTerratagLocals(locals Locals) string {
 
 func MergeTerratagLocals(attribute *hclwrite.Attribute, added string) (string, error) {
 	localsAttribute := Locals{}
-	tokens := hclwrite.Tokens{}
-	existingLocalsExpression := stringifyExpression(attribute.BuildTokens(tokens))
+	existingLocalsExpression := stringifyExpression(attribute.Expr().BuildTokens(hclwrite.Tokens{}))
 
 	if err := decodeTerratagLocals(localsAttribute, existingLocalsExpression); err != nil {
 		return "", err
@@ -0,0 +1,19 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTerratagLocals(t *testing.T) {
+	file, diags := hclwrite.ParseConfig([]byte(`terratag_added_main = {"a"="b","owner"=var.owner,"c"="d \"e\""}`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	merged, err := MergeTerratagLocals(file.Body().GetAttribute("terratag_added_main"), `{"a"=lower("B"),"f"="g"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a" = lower("B"), "c" = "d \"e\"", "f" = "g", "owner" = var.owner}`, merged)
+}
@@ -1,21 +1,30 @@
 package file
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/env0/terratag/internal/common"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
 )
 
 func ReplaceWithTerratagFile(path string, textContent string, rename bool) error {
 	backupFilename := path + ".bak"
 
 	if rename {
-		taggedFilename := strings.TrimSuffix(path, filepath.Ext(path)) + ".terratag.tf"
+		taggedFilename := GetTerratagFilename(path)
 		if err := CreateFile(taggedFilename, textContent); err != nil {
 			return err
 		}
@@ -36,6 +45,51 @@ func ReplaceWithTerratagFile(path string, textContent string, rename bool) error
 	return nil
 }
 
+// RestoreFromBackup reverts ReplaceWithTerratagFile (in both rename and in-place modes).
+// The terratag file (if any) is removed and the backup file is moved back to the original path.
+func RestoreFromBackup(backupPath string) error {
+	path := strings.TrimSuffix(backupPath, ".bak")
+	taggedFilename := GetTerratagFilename(path)
+
+	if _, err := os.Stat(taggedFilename); err == nil {
+		log.Print("[INFO] Removing file ", taggedFilename)
+
+		if err := os.Remove(taggedFilename); err != nil {
+			return err
+		}
+	}
+
+	log.Print("[INFO] Restoring ", backupPath, " to ", path)
+
+	return os.Rename(backupPath, path)
+}
+
+// GetTerratagFileDiff returns a unified diff between the file at path and the
+// content ReplaceWithTerratagFile would write for it, without touching the disk.
+func GetTerratagFileDiff(path string, textContent string, rename bool) (string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	toFile := path
+	if rename {
+		toFile = GetTerratagFilename(path)
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(src)),
+		B:        difflib.SplitLines(textContent),
+		FromFile: path,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+func GetTerratagFilename(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".terratag.tf"
+}
+
 func CreateFile(path string, textContent string) error {
 	log.Print("[INFO] Creating file ", path)
 
@@ -63,3 +117,111 @@ func ReadHCLFile(path string) (*hclwrite.File, error) {
 
 	return file, nil
 }
+
+// GetBlockLines returns the line each top level block of the file starts on, in order
+// (matching hclwrite's Body().Blocks(), as hclwrite does not keep source positions).
+func GetBlockLines(path string) ([]int, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, diagnostics := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if err := multierr.Combine(diagnostics.Errs()...); err != nil {
+		return nil, err
+	}
+
+	lines := []int{}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return lines, nil
+	}
+
+	for _, block := range body.Blocks {
+		lines = append(lines, block.TypeRange.Start.Line)
+	}
+
+	return lines, nil
+}
+
+// ReadTagsFile reads tags from a JSON, YAML or HCL (a "tags = {...}" attribute) file.
+// The format is determined by the file extension.
+func ReadTagsFile(path string) (map[string]string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+
+	switch filepath.Ext(path) {
+	case ".json":
+		if err := json.Unmarshal(src, &tags); err != nil {
+			return nil, fmt.Errorf("failed to parse tags file %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(src, &tags); err != nil {
+			return nil, fmt.Errorf("failed to parse tags file %s: %w", path, err)
+		}
+	case ".hcl", ".tf", ".tfvars":
+		if err := readHCLTagsFile(path, src, tags); err != nil {
+			return nil, fmt.Errorf("failed to parse tags file %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tags file %s, must be a .json, .yaml, .yml or .hcl file", path)
+	}
+
+	return tags, nil
+}
+
+func readHCLTagsFile(path string, src []byte, tags map[string]string) error {
+	file, diagnostics := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if err := multierr.Combine(diagnostics.Errs()...); err != nil {
+		return err
+	}
+
+	attributes, diagnostics := file.Body.JustAttributes()
+	if err := multierr.Combine(diagnostics.Errs()...); err != nil {
+		return err
+	}
+
+	attribute, ok := attributes["tags"]
+	if !ok {
+		return errors.New("missing tags attribute")
+	}
+
+	object, ok := attribute.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return errors.New("tags attribute must be a map")
+	}
+
+	for _, item := range object.Items {
+		key, diagnostics := item.KeyExpr.Value(nil)
+		if err := multierr.Combine(diagnostics.Errs()...); err != nil {
+			return err
+		}
+
+		key, err := convert.Convert(key, cty.String)
+		if err != nil || key.IsNull() {
+			return errors.New("tag keys must be strings")
+		}
+
+		// Values that can't be evaluated (e.g. var.owner or timestamp()) are kept as expressions.
+		value, diagnostics := item.ValueExpr.Value(nil)
+		if diagnostics.HasErrors() {
+			tags[key.AsString()] = common.ExpressionPrefix + string(item.ValueExpr.Range().SliceBytes(src))
+
+			continue
+		}
+
+		value, err = convert.Convert(value, cty.String)
+		if err != nil || value.IsNull() {
+			return fmt.Errorf("tag %s must be a string", key.AsString())
+		}
+
+		tags[key.AsString()] = value.AsString()
+	}
+
+	return nil
+}
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -12,73 +16,238 @@ import (
 )
 
 const AWS = "aws"
+const AWSCC = "awscc"
 const GCP = "gcp"
 const AZURE = "azure"
+const OCI = "oci"
+const ALICLOUD = "alicloud"
+const TENCENTCLOUD = "tencentcloud"
+const HUAWEICLOUD = "huaweicloud"
+const KUBERNETES = "kubernetes"
+
+// DefaultTags is where a provider block sets the tags of all its resources.
+type DefaultTags struct {
+	// Block is the nested block of the attribute (empty if it's a provider block attribute).
+	Block     string
+	Attribute string
+	// MinMajorVersion is the first major version of the provider supporting the default tags (0 if always supported).
+	MinMajorVersion int
+}
+
+// IsSupportedVersion checks if the provider version (as found in the dependency lock file) supports the default tags.
+func (d DefaultTags) IsSupportedVersion(version string) bool {
+	if d.MinMajorVersion == 0 {
+		return true
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimPrefix(version, "v"), ".", 2)[0])
+	if err != nil {
+		return false
+	}
+
+	return major >= d.MinMajorVersion
+}
+
+var providerBlocksDefaultTags = map[string]DefaultTags{
+	"aws": {Block: "default_tags", Attribute: "tags"},
+	// https://registry.terraform.io/providers/hashicorp/google/latest/docs/guides/version_5_upgrade#provider-level-labels-rework
+	"google":      {Attribute: "default_labels", MinMajorVersion: 5},
+	"google-beta": {Attribute: "default_labels", MinMajorVersion: 5},
+}
+
+var providerBlocks = map[string]Provider{
+	"aws":         AWS,
+	"google":      GCP,
+	"google-beta": GCP,
+}
+
+// Resources that do not honor the provider default tags.
+var resourcesIgnoringDefaultTags = []string{"aws_autoscaling_group"}
 
 var resourcesToSkip = []string{"azurerm_api_management_named_value"}
 
-var prefixes = map[string]Provider{
-	"aws_":        AWS,
-	"google_":     GCP,
-	"azurerm_":    AZURE,
-	"azurestack_": AZURE,
-	"azapi_":      AZURE,
+// ResourcePrefix maps the resources with a type prefix to their provider and tags attribute.
+type ResourcePrefix struct {
+	Prefix   string
+	Provider Provider
+	// TagAttribute is the tags attribute, or a path of nested blocks ending with the attribute (e.g. metadata.labels).
+	TagAttribute string
+	// TagsFormat is the shape of the tags attribute (see tagging.TagsFormat). Detected from the schema when empty.
+	TagsFormat string
+}
+
+// CustomResourcePrefixes are user-defined providers (see config.Providers). They take precedence over the built-in
+// providers (the only ones used by a nil CustomResourcePrefixes).
+type CustomResourcePrefixes []ResourcePrefix
+
+// resourcePrefixes are the supported providers. The longest matching prefix wins.
+var resourcePrefixes = []ResourcePrefix{
+	{Prefix: "aws_", Provider: AWS, TagAttribute: "tags"},
+	{Prefix: "awscc_", Provider: AWSCC, TagAttribute: "tags"},
+	{Prefix: "google_", Provider: GCP, TagAttribute: "labels"},
+	{Prefix: "azurerm_", Provider: AZURE, TagAttribute: "tags"},
+	{Prefix: "azurestack_", Provider: AZURE, TagAttribute: "tags"},
+	{Prefix: "azapi_", Provider: AZURE, TagAttribute: "tags"},
+	// The defined tags may be used instead (see config.GetOCITagAttribute).
+	{Prefix: "oci_", Provider: OCI, TagAttribute: "freeform_tags"},
+	{Prefix: "alicloud_", Provider: ALICLOUD, TagAttribute: "tags"},
+	{Prefix: "tencentcloud_", Provider: TENCENTCLOUD, TagAttribute: "tags"},
+	{Prefix: "huaweicloud_", Provider: HUAWEICLOUD, TagAttribute: "tags"},
+	// The pod templates of workloads may be labeled too (see config.Kubernetes).
+	{Prefix: "kubernetes_", Provider: KUBERNETES, TagAttribute: "metadata.labels"},
+}
+
+// resourceTagAttributes are the tags attributes of the resource types that don't use their provider's.
+var resourceTagAttributes = map[string]string{
+	// Node pools have no labels, their node VMs do (see tagging.tagContainerNodePool).
+	"google_container_node_pool": "node_config.resource_labels",
 }
 
+//go:generate go run ../../azapi -input=$AZURE_TAG_SUPPORT -output=azure_resource_tag_support.csv
+
+// azureTagSupport is generated from Microsoft's tag support table (see the azapi directory).
+// Tables without the "Supports tags" column list only the taggable resource types, and tables without the
+// "Tag in cost report" column are assumed to show the tags in the cost reports.
+//
 //go:embed azure_resource_tag_support.csv
 var azureTagSupport []byte
 
+// AzureTagSupport is the tag support of an Azure resource type.
+// See: https://learn.microsoft.com/en-us/azure/azure-resource-manager/management/tag-support
+type AzureTagSupport struct {
+	SupportsTags     bool
+	TagsInCostReport bool
+}
+
 var (
-	azureTaggableResources map[string]bool
-	once                   sync.Once
+	azureTagSupportByType map[string]AzureTagSupport
+	once                  sync.Once
 )
 
-func getAzureTaggableResources() map[string]bool {
+func getAzureTagSupportByType() map[string]AzureTagSupport {
 	once.Do(func() {
-		reader := csv.NewReader(bytes.NewReader(azureTagSupport))
-		records, err := reader.ReadAll()
+		var err error
 
+		azureTagSupportByType, err = parseAzureTagSupport(azureTagSupport)
 		if err != nil {
-			panic(err) // Or handle the error appropriately
+			panic(err)
 		}
+	})
+
+	return azureTagSupportByType
+}
+
+func parseAzureTagSupport(data []byte) (map[string]AzureTagSupport, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure tag support table: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("invalid azure tag support table: missing header")
+	}
 
-		azureTaggableResources = make(map[string]bool)
-		for _, record := range records {
-			azureTaggableResources[record[0]] = true
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(name)] = i
+	}
+
+	isYes := func(record []string, column string) bool {
+		i, ok := columns[column]
+
+		return !ok || (i < len(record) && strings.EqualFold(record[i], "yes"))
+	}
+
+	tagSupportByType := make(map[string]AzureTagSupport, len(records)-1)
+	for _, record := range records[1:] {
+		tagSupportByType[strings.ToLower(record[0])] = AzureTagSupport{
+			SupportsTags:     isYes(record, "supports tags"),
+			TagsInCostReport: isYes(record, "tag in cost report"),
 		}
-	})
+	}
+
+	return tagSupportByType, nil
+}
 
-	return azureTaggableResources
+// getResourcePrefix returns the longest resource prefix matching the resource type (nil if the provider is not supported).
+func (c CustomResourcePrefixes) getResourcePrefix(resourceType string) *ResourcePrefix {
+	if match := matchResourcePrefix(c, resourceType); match != nil {
+		return match
+	}
+
+	return matchResourcePrefix(resourcePrefixes, resourceType)
 }
 
-func getProviderByResource(resourceType string) Provider {
-	for prefix, provider := range prefixes {
-		if strings.HasPrefix(resourceType, prefix) {
-			return provider
+func matchResourcePrefix(prefixes []ResourcePrefix, resourceType string) *ResourcePrefix {
+	var match *ResourcePrefix
+
+	for i, resourcePrefix := range prefixes {
+		if strings.HasPrefix(resourceType, resourcePrefix.Prefix) && (match == nil || len(resourcePrefix.Prefix) > len(match.Prefix)) {
+			match = &prefixes[i]
 		}
 	}
 
-	return ""
+	if match == nil {
+		return nil
+	}
+
+	resourcePrefix := *match
+
+	return &resourcePrefix
+}
+
+func GetProviderByResource(resourceType string) Provider {
+	return CustomResourcePrefixes(nil).GetProviderByResource(resourceType)
 }
 
-func IsTaggableByAttribute(resourceType string, attribute string) bool {
-	provider := getProviderByResource(resourceType)
-	tagBlockId := GetTagIdByResource(resourceType)
+func (c CustomResourcePrefixes) GetProviderByResource(resourceType string) Provider {
+	if resourcePrefix := c.getResourcePrefix(resourceType); resourcePrefix != nil {
+		return resourcePrefix.Provider
+	}
 
-	if (provider != "") && attribute == tagBlockId {
-		return true
+	return ""
+}
+
+// GetDefaultTagsByProviderBlock returns the provider (and where its default tags are set) of a provider block.
+func GetDefaultTagsByProviderBlock(name string) (Provider, *DefaultTags) {
+	defaultTags, ok := providerBlocksDefaultTags[name]
+	if !ok {
+		return providerBlocks[name], nil
 	}
 
-	return false
+	return providerBlocks[name], &defaultTags
 }
 
+func IsIgnoringDefaultTags(resourceType string) bool {
+	return slices.Contains(resourcesIgnoringDefaultTags, resourceType)
+}
+
+// GetTagIdByResource returns the tags attribute of a resource (a path for attributes of nested blocks e.g. metadata.labels).
 func GetTagIdByResource(resourceType string) string {
-	provider := getProviderByResource(resourceType)
+	return CustomResourcePrefixes(nil).GetTagIdByResource(resourceType)
+}
+
+func (c CustomResourcePrefixes) GetTagIdByResource(resourceType string) string {
+	if matchResourcePrefix(c, resourceType) == nil {
+		if tagAttribute, ok := resourceTagAttributes[resourceType]; ok {
+			return tagAttribute
+		}
+	}
 
-	if provider == "aws" || provider == "azure" {
-		return "tags"
-	} else if provider == "gcp" {
-		return "labels"
+	if resourcePrefix := c.getResourcePrefix(resourceType); resourcePrefix != nil {
+		return resourcePrefix.TagAttribute
+	}
+
+	return ""
+}
+
+// GetTagsFormatByResource returns the configured shape of the resource tags attribute (empty if it should be detected from the schema).
+func (c CustomResourcePrefixes) GetTagsFormatByResource(resourceType string) string {
+	if resourcePrefix := c.getResourcePrefix(resourceType); resourcePrefix != nil {
+		return resourcePrefix.TagsFormat
 	}
 
 	return ""
@@ -95,68 +264,68 @@ func isSimpleStringLiteral(tokens hclwrite.Tokens) (string, bool) {
 	return "", false
 }
 
-// For more details check: https://github.com/env0/terratag/issues/209
-func isAzureTypeTaggable(resource hclwrite.Block) bool {
+// GetAzapiType returns the Azure resource type (without the API version) of an azapi resource.
+// The type may be a string literal, or a reference to a string local or variable default (see terraform.GetStringReferences).
+func GetAzapiType(resource hclwrite.Block, references map[string]string) (string, bool) {
 	typeAttr := resource.Body().GetAttribute("type")
 
 	if typeAttr == nil {
-		return false
+		return "", false
 	}
 
 	typeTokens := typeAttr.Expr().BuildTokens(nil)
 
 	typeValue, ok := isSimpleStringLiteral(typeTokens)
+	if !ok {
+		typeValue, ok = references[strings.TrimSpace(string(typeTokens.Bytes()))]
+	}
 
 	if !ok || typeValue == "" {
-		return false
+		return "", false
 	}
 
-	// split the type value to get the resource type, get everything before "@" lower cased.
+	// split the type value to get the resource type, everything before "@".
 	parts := strings.Split(typeValue, "@")
 	if len(parts) != 2 {
-		return false
+		return "", false
 	}
 
-	resourceType := strings.ToLower(parts[0])
+	return parts[0], true
+}
+
+// GetAzureTagSupport returns the tag support of an Azure resource type (e.g. Microsoft.Storage/storageAccounts).
+// Returns false if the resource type is unknown.
+func GetAzureTagSupport(azureType string) (AzureTagSupport, bool) {
+	support, ok := getAzureTagSupportByType()[strings.ToLower(azureType)]
 
-	// check if the resource type is taggable based on the on list of supported azure tags.
-	azureTaggableResources := getAzureTaggableResources()
-	if _, ok := azureTaggableResources[resourceType]; !ok {
-		return false
-	}
+	return support, ok
+}
+
+// IsAzureTypeTaggable checks if an Azure resource type is taggable based on the list of supported azure tags.
+// For more details check: https://github.com/env0/terratag/issues/209
+func IsAzureTypeTaggable(azureType string) bool {
+	support, ok := GetAzureTagSupport(azureType)
 
-	return true
+	return ok && support.SupportsTags
 }
 
-func IsSupportedResource(resourceType string, resource hclwrite.Block) bool {
+// IsAzapiResource checks if the resource type is of the azapi provider (its Azure resource type is set by its "type", see GetAzapiType).
+func IsAzapiResource(resourceType string) bool {
+	return strings.HasPrefix(resourceType, "azapi_")
+}
+
+func IsSupportedResource(resourceType string) bool {
+	return CustomResourcePrefixes(nil).IsSupportedResource(resourceType)
+}
+
+func (c CustomResourcePrefixes) IsSupportedResource(resourceType string) bool {
 	for _, resourceToSkip := range resourcesToSkip {
 		if resourceType == resourceToSkip {
 			return false
 		}
 	}
 
-	if !isSupportedProvider(getProviderByResource(resourceType)) {
-		return false
-	}
-
-	if strings.HasPrefix(resourceType, "azapi_") && !isAzureTypeTaggable(resource) {
-		return false
-	}
-
-	return true
-}
-
-func isSupportedProvider(provider Provider) bool {
-	switch provider {
-	case "aws":
-		return true
-	case "gcp":
-		return true
-	case "azure":
-		return true
-	default:
-		return false
-	}
+	return c.getResourcePrefix(resourceType) != nil
 }
 
 type Provider string
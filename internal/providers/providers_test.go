@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTagsIsSupportedVersion(t *testing.T) {
+	_, aws := GetDefaultTagsByProviderBlock("aws")
+	_, google := GetDefaultTagsByProviderBlock("google")
+
+	assert.True(t, aws.IsSupportedVersion(""))
+	assert.True(t, aws.IsSupportedVersion("3.0.0"))
+	assert.False(t, google.IsSupportedVersion(""))
+	assert.False(t, google.IsSupportedVersion("4.84.0"))
+	assert.True(t, google.IsSupportedVersion("5.0.0"))
+	assert.True(t, google.IsSupportedVersion("6.12.1"))
+}
+
+func TestGetProviderByResource(t *testing.T) {
+	testCases := []struct {
+		resourceType string
+		provider     Provider
+		tagId        string
+	}{
+		{"aws_s3_bucket", AWS, "tags"},
+		{"awscc_s3_bucket", AWSCC, "tags"},
+		{"google_storage_bucket", GCP, "labels"},
+		{"google_container_node_pool", GCP, "node_config.resource_labels"},
+		{"azurerm_resource_group", AZURE, "tags"},
+		{"azapi_resource", AZURE, "tags"},
+		{"oci_core_vcn", OCI, "freeform_tags"},
+		{"alicloud_vpc", ALICLOUD, "tags"},
+		{"tencentcloud_vpc", TENCENTCLOUD, "tags"},
+		{"huaweicloud_vpc", HUAWEICLOUD, "tags"},
+		{"kubernetes_namespace", KUBERNETES, "metadata.labels"},
+		{"random_string", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.resourceType, func(t *testing.T) {
+			assert.Equal(t, tc.provider, GetProviderByResource(tc.resourceType))
+			assert.Equal(t, tc.tagId, GetTagIdByResource(tc.resourceType))
+		})
+	}
+}
+
+func TestCustomResourcePrefixes(t *testing.T) {
+	customResourcePrefixes := CustomResourcePrefixes{
+		{Prefix: "datadog_", Provider: "datadog", TagAttribute: "tags", TagsFormat: "key_value_strings"},
+		{Prefix: "google_storage_", Provider: "storage", TagAttribute: "labels"},
+		{Prefix: "google_container_", Provider: "container", TagAttribute: "labels"},
+	}
+
+	assert.Equal(t, Provider("datadog"), customResourcePrefixes.GetProviderByResource("datadog_monitor"))
+	assert.Equal(t, "key_value_strings", customResourcePrefixes.GetTagsFormatByResource("datadog_monitor"))
+	assert.True(t, customResourcePrefixes.IsSupportedResource("datadog_monitor"))
+	assert.Equal(t, Provider("storage"), customResourcePrefixes.GetProviderByResource("google_storage_bucket"))
+	assert.Equal(t, Provider(GCP), customResourcePrefixes.GetProviderByResource("google_compute_instance"))
+	assert.Empty(t, customResourcePrefixes.GetTagsFormatByResource("google_compute_instance"))
+	assert.Equal(t, "labels", customResourcePrefixes.GetTagIdByResource("google_container_node_pool"))
+
+	// The custom providers are only used by their own lookups.
+	assert.Equal(t, Provider(GCP), GetProviderByResource("google_storage_bucket"))
+	assert.False(t, IsSupportedResource("datadog_monitor"))
+}
+
+func TestGetAzapiType(t *testing.T) {
+	references := map[string]string{
+		"local.registry_type": "Microsoft.ContainerRegistry/registries@2020-11-01-preview",
+	}
+
+	testCases := []struct {
+		name      string
+		typeValue string
+		azureType string
+		ok        bool
+	}{
+		{"literal", `"Microsoft.Storage/storageAccounts@2023-01-01"`, "Microsoft.Storage/storageAccounts", true},
+		{"reference", "local.registry_type", "Microsoft.ContainerRegistry/registries", true},
+		{"unknown reference", "var.type", "", false},
+		{"missing version", `"Microsoft.Storage/storageAccounts"`, "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, diags := hclwrite.ParseConfig([]byte("resource \"azapi_resource\" \"a\" {\n  type = "+tc.typeValue+"\n}\n"), "main.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors())
+
+			azureType, ok := GetAzapiType(*f.Body().Blocks()[0], references)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.azureType, azureType)
+		})
+	}
+
+	assert.True(t, IsAzureTypeTaggable("microsoft.storage/storageAccounts"))
+}
+
+func TestParseAzureTagSupport(t *testing.T) {
+	tagSupport, err := parseAzureTagSupport([]byte(`Resource type,Supports tags,Tag in cost report
+microsoft.storage/storageaccounts,Yes,Yes
+microsoft.network/dnszones,Yes,No
+microsoft.aad/domainservices/oucontainer,No,No
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]AzureTagSupport{
+		"microsoft.storage/storageaccounts":        {SupportsTags: true, TagsInCostReport: true},
+		"microsoft.network/dnszones":               {SupportsTags: true, TagsInCostReport: false},
+		"microsoft.aad/domainservices/oucontainer": {SupportsTags: false, TagsInCostReport: false},
+	}, tagSupport)
+
+	// Older tables list only the taggable resource types.
+	tagSupport, err = parseAzureTagSupport([]byte("Resource type\nmicrosoft.storage/storageaccounts\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]AzureTagSupport{
+		"microsoft.storage/storageaccounts": {SupportsTags: true, TagsInCostReport: true},
+	}, tagSupport)
+}
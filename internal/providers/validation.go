@@ -0,0 +1,178 @@
+package providers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/env0/terratag/internal/common"
+)
+
+type tagRules struct {
+	maxKeyLength   int
+	maxValueLength int
+	// lowercase - keys and values must be lowercase.
+	lowercase bool
+	// invalidKeyChars and invalidValueChars match the characters that are not allowed (replaced with "_" when normalizing).
+	invalidKeyChars   *regexp.Regexp
+	invalidValueChars *regexp.Regexp
+	// keyPattern and valuePattern must match the normalized keys and values (can't be normalized).
+	keyPattern   *regexp.Regexp
+	valuePattern *regexp.Regexp
+	// reservedKeyPrefixes can't be used (can't be normalized).
+	reservedKeyPrefixes []string
+}
+
+var gcpInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+var awsTagRules = tagRules{
+	maxKeyLength:        128,
+	maxValueLength:      256,
+	reservedKeyPrefixes: []string{"aws:"},
+}
+
+// See:
+// https://docs.aws.amazon.com/tag-editor/latest/userguide/tagging.html#tag-conventions
+// https://cloud.google.com/resource-manager/docs/labels-overview#requirements
+// https://learn.microsoft.com/en-us/azure/azure-resource-manager/management/tag-resources#limitations
+// https://docs.oracle.com/en-us/iaas/Content/Tagging/Concepts/taggingoverview.htm#limits
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set
+var providerTagRules = map[Provider]tagRules{
+	AWS:   awsTagRules,
+	AWSCC: awsTagRules,
+	GCP: {
+		maxKeyLength:      63,
+		maxValueLength:    63,
+		lowercase:         true,
+		invalidKeyChars:   gcpInvalidChars,
+		invalidValueChars: gcpInvalidChars,
+		keyPattern:        regexp.MustCompile(`^[a-z]`),
+	},
+	AZURE: {
+		maxKeyLength:    512,
+		maxValueLength:  256,
+		invalidKeyChars: regexp.MustCompile(`[<>%&\\?/]`),
+	},
+	OCI: {
+		maxKeyLength:    100,
+		maxValueLength:  256,
+		invalidKeyChars: regexp.MustCompile(`[.\s]`),
+	},
+	KUBERNETES: {
+		// An optional DNS subdomain prefix (253 characters) and a name (63 characters).
+		maxKeyLength:        317,
+		maxValueLength:      63,
+		invalidKeyChars:     regexp.MustCompile(`[^a-zA-Z0-9_./-]`),
+		invalidValueChars:   regexp.MustCompile(`[^a-zA-Z0-9_.-]`),
+		keyPattern:          regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?[a-zA-Z0-9]([-a-zA-Z0-9_.]{0,61}[a-zA-Z0-9])?$`),
+		valuePattern:        regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?)?$`),
+		reservedKeyPrefixes: []string{"kubernetes.io/", "k8s.io/"},
+	},
+}
+
+// TagNormalization describes a tag that was transformed to comply with the provider rules.
+type TagNormalization struct {
+	Key             string `json:"key"`
+	Value           string `json:"value"`
+	NormalizedKey   string `json:"normalized_key"`
+	NormalizedValue string `json:"normalized_value"`
+}
+
+func (n TagNormalization) String() string {
+	return fmt.Sprintf("%s=%s => %s=%s", n.Key, n.Value, n.NormalizedKey, n.NormalizedValue)
+}
+
+// ValidateTags validates the tags keys and values against the provider rules.
+// If normalize is set, invalid tags are transformed (when possible) instead of returning an error.
+// Expression values (see common.ExpressionPrefix) are not validated.
+func ValidateTags(provider Provider, tags map[string]string, normalize bool) (map[string]string, []TagNormalization, error) {
+	rules, ok := providerTagRules[provider]
+	if !ok {
+		return tags, nil, nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	validated := map[string]string{}
+
+	var normalizations []TagNormalization
+
+	for _, key := range keys {
+		value := tags[key]
+
+		for _, reservedKeyPrefix := range rules.reservedKeyPrefixes {
+			if strings.HasPrefix(strings.ToLower(key), reservedKeyPrefix) {
+				return nil, nil, fmt.Errorf("invalid %s tag key %s: the %s prefix is reserved", provider, key, reservedKeyPrefix)
+			}
+		}
+
+		normalizedKey := normalizeTag(key, rules.lowercase, rules.invalidKeyChars, rules.maxKeyLength)
+		normalizedValue := value
+
+		if !strings.HasPrefix(value, common.ExpressionPrefix) {
+			normalizedValue = normalizeTag(value, rules.lowercase, rules.invalidValueChars, rules.maxValueLength)
+		}
+
+		if rules.keyPattern != nil && !rules.keyPattern.MatchString(normalizedKey) {
+			return nil, nil, fmt.Errorf("invalid %s tag key %s: must match %s", provider, key, rules.keyPattern)
+		}
+
+		if rules.valuePattern != nil && !strings.HasPrefix(value, common.ExpressionPrefix) && !rules.valuePattern.MatchString(normalizedValue) {
+			return nil, nil, fmt.Errorf("invalid %s tag %s value %s: must match %s", provider, key, value, rules.valuePattern)
+		}
+
+		if normalizedKey != key || normalizedValue != value {
+			if !normalize {
+				return nil, nil, fmt.Errorf("invalid %s tag %s=%s (use -normalize to transform it to %s=%s)", provider, key, value, normalizedKey, normalizedValue)
+			}
+
+			normalizations = append(normalizations, TagNormalization{
+				Key:             key,
+				Value:           value,
+				NormalizedKey:   normalizedKey,
+				NormalizedValue: normalizedValue,
+			})
+		}
+
+		if _, ok := validated[normalizedKey]; ok {
+			return nil, nil, fmt.Errorf("invalid %s tag key %s: conflicts with another tag key once normalized to %s", provider, key, normalizedKey)
+		}
+
+		validated[normalizedKey] = normalizedValue
+	}
+
+	return validated, normalizations, nil
+}
+
+func normalizeTag(s string, lowercase bool, invalidChars *regexp.Regexp, maxLength int) string {
+	if lowercase {
+		s = strings.ToLower(s)
+	}
+
+	if invalidChars != nil {
+		s = invalidChars.ReplaceAllString(s, "_")
+	}
+
+	if runes := []rune(s); len(runes) > maxLength {
+		s = string(runes[:maxLength])
+	}
+
+	return s
+}
+
+// QualifyDefinedTags returns the tags as OCI defined tags of the namespace (e.g. Operations.CostCenter).
+func QualifyDefinedTags(namespace string, tags map[string]string) map[string]string {
+	definedTags := make(map[string]string, len(tags))
+
+	for key, value := range tags {
+		definedTags[namespace+"."+key] = value
+	}
+
+	return definedTags
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTags(t *testing.T) {
+	testCases := []struct {
+		name       string
+		provider   Provider
+		tags       map[string]string
+		normalized map[string]string
+		invalid    bool
+	}{
+		{name: "valid aws", provider: AWS, tags: map[string]string{"Owner": "Team A/B"}, normalized: map[string]string{"Owner": "Team A/B"}},
+		{name: "aws reserved prefix", provider: AWS, tags: map[string]string{"aws:owner": "a"}, invalid: true},
+		{name: "aws long key", provider: AWS, tags: map[string]string{strings.Repeat("k", 130): "a"}, normalized: map[string]string{strings.Repeat("k", 128): "a"}},
+		{name: "gcp", provider: GCP, tags: map[string]string{"Owner": "Team A", "env": "expr:var.Env"}, normalized: map[string]string{"owner": "team_a", "env": "expr:var.Env"}},
+		{name: "gcp key must start with a letter", provider: GCP, tags: map[string]string{"1owner": "a"}, invalid: true},
+		{name: "gcp conflicting keys", provider: GCP, tags: map[string]string{"Owner": "a", "owner": "b"}, invalid: true},
+		{name: "azure", provider: AZURE, tags: map[string]string{"a/b": "c/d"}, normalized: map[string]string{"a_b": "c/d"}},
+		{name: "oci", provider: OCI, tags: map[string]string{"cost.center": "a.b c"}, normalized: map[string]string{"cost_center": "a.b c"}},
+		{name: "kubernetes", provider: KUBERNETES, tags: map[string]string{"app.example.com/Owner": "Team A", "env": ""}, normalized: map[string]string{"app.example.com/Owner": "Team_A", "env": ""}},
+		{name: "kubernetes long value", provider: KUBERNETES, tags: map[string]string{"owner": strings.Repeat("v", 70)}, normalized: map[string]string{"owner": strings.Repeat("v", 63)}},
+		{name: "kubernetes invalid key", provider: KUBERNETES, tags: map[string]string{"-owner": "a"}, invalid: true},
+		{name: "kubernetes invalid key prefix", provider: KUBERNETES, tags: map[string]string{"Example.com/owner": "a"}, invalid: true},
+		{name: "kubernetes invalid value", provider: KUBERNETES, tags: map[string]string{"owner": "team a "}, invalid: true},
+		{name: "kubernetes reserved prefix", provider: KUBERNETES, tags: map[string]string{"kubernetes.io/owner": "a"}, invalid: true},
+		{name: "unknown provider", provider: "other", tags: map[string]string{"A B": "c"}, normalized: map[string]string{"A B": "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validated, _, err := ValidateTags(tc.provider, tc.tags, true)
+			if tc.invalid {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.normalized, validated)
+
+			// Without normalization, invalid tags are rejected.
+			_, normalizations, err := ValidateTags(tc.provider, tc.tags, false)
+			assert.Equal(t, len(normalizations) > 0 || err != nil, !assert.ObjectsAreEqual(tc.tags, tc.normalized))
+		})
+	}
+}
+
+func TestQualifyDefinedTags(t *testing.T) {
+	assert.Equal(t, map[string]string{"Operations.CostCenter": "42"}, QualifyDefinedTags("Operations", map[string]string{"CostCenter": "42"}))
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/env0/terratag/internal/providers"
+)
+
+type FileStatus string
+
+const (
+	// FileProcessed - the file was processed, but no resource was tagged.
+	FileProcessed FileStatus = "processed"
+	FileTagged    FileStatus = "tagged"
+	// FileSkipped - previously tagged terratag files.
+	FileSkipped FileStatus = "skipped"
+	FileError   FileStatus = "error"
+)
+
+type ResourceStatus string
+
+const (
+	ResourceTagged  ResourceStatus = "tagged"
+	ResourceSkipped ResourceStatus = "skipped"
+	// ResourceUntagged - a taggable resource that is not tagged yet (check mode).
+	ResourceUntagged ResourceStatus = "untagged"
+)
+
+type SkipReason string
+
+const (
+	SkipReasonFilter         SkipReason = "filter"
+	SkipReasonSkip           SkipReason = "skip"
+	SkipReasonNotTaggable    SkipReason = "not_taggable"
+	SkipReasonSchemaNotFound SkipReason = "schema_not_found"
+	// SkipReasonUnknownType - the Azure resource type of an azapi resource can't be determined.
+	SkipReasonUnknownType SkipReason = "unknown_type"
+	// SkipReasonProviderDefaults - tagged by the provider default tags (provider-defaults strategy).
+	SkipReasonProviderDefaults SkipReason = "provider_defaults"
+)
+
+type Resource struct {
+	Type         string         `json:"type"`
+	Name         string         `json:"name"`
+	Line         int            `json:"line"`
+	Provider     string         `json:"provider,omitempty"`
+	Status       ResourceStatus `json:"status"`
+	SkipReason   SkipReason     `json:"skip_reason,omitempty"`
+	TagAttribute string         `json:"tag_attribute,omitempty"`
+	TagsLocal    string         `json:"tags_local,omitempty"`
+	// Normalizations are the tags transformed to comply with the provider rules.
+	Normalizations     []providers.TagNormalization `json:"normalizations,omitempty"`
+	MergedExistingTags bool                         `json:"merged_existing_tags"`
+	// NestedTagPaths are the tagged nested block attributes (e.g. root_block_device.tags).
+	NestedTagPaths []string `json:"nested_tag_paths,omitempty"`
+}
+
+type File struct {
+	Path      string     `json:"path"`
+	Status    FileStatus `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Resources []Resource `json:"resources,omitempty"`
+}
+
+type Summary struct {
+	TotalResources  uint32 `json:"total_resources"`
+	TaggedResources uint32 `json:"tagged_resources"`
+	TotalFiles      uint32 `json:"total_files"`
+	TaggedFiles     uint32 `json:"tagged_files"`
+}
+
+// Report collects the results of a terratag run. It is safe for concurrent use.
+type Report struct {
+	lock    sync.Mutex
+	Summary Summary `json:"summary"`
+	Files   []*File `json:"files"`
+}
+
+func (r *Report) AddFile(file *File) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.Files = append(r.Files, file)
+}
+
+// GetResources returns all reported resources (sorted by file and line) with the given status.
+func (r *Report) GetResources(status ResourceStatus) map[string][]Resource {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resources := map[string][]Resource{}
+
+	for _, file := range r.Files {
+		for _, resource := range file.Resources {
+			if resource.Status == status {
+				resources[file.Path] = append(resources[file.Path], resource)
+			}
+		}
+	}
+
+	for _, fileResources := range resources {
+		sort.Slice(fileResources, func(i, j int) bool {
+			return fileResources[i].Line < fileResources[j].Line
+		})
+	}
+
+	return resources
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	sort.Slice(r.Files, func(i, j int) bool {
+		return r.Files[i].Path < r.Files[j].Path
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
@@ -6,8 +6,23 @@ import (
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
+const terratagAddedPrefix = "terratag_added_"
+
 func GetTerratagAddedKey(filname string) string {
-	return "terratag_added_" + filname
+	return terratagAddedPrefix + filname
+}
+
+// GetTerratagAddedSetKey is the locals name of an additional tag set (see config.GetTagSet).
+func GetTerratagAddedSetKey(filename string, tagSet string) string {
+	if tagSet == "" {
+		return GetTerratagAddedKey(filename)
+	}
+
+	return GetTerratagAddedKey(filename) + "__" + tagSet
+}
+
+func IsTerratagAddedKey(key string) bool {
+	return strings.HasPrefix(key, terratagAddedPrefix)
 }
 
 func GetResourceExistingTagsKey(filename string, resource *hclwrite.Block) string {
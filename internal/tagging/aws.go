@@ -2,13 +2,20 @@ package tagging
 
 import (
 	"encoding/json"
+	"log"
+	"slices"
 	"strings"
 
 	"github.com/env0/terratag/internal/convert"
-	"github.com/env0/terratag/internal/tag_keys"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
+// launchTemplateResourceTypes are the tag_specifications resource types tagged by tagAwsLaunchTemplate.
+var launchTemplateResourceTypes = []string{"instance", "volume", "network-interface"}
+
 func tagAwsInstance(args TagBlockArgs) (*Result, error) {
 	var swappedTagsStrings []string
 
@@ -78,6 +85,167 @@ func tagAwsInstance(args TagBlockArgs) (*Result, error) {
 	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
 }
 
+func tagAwsLaunchTemplate(args TagBlockArgs) (*Result, error) {
+	// https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/launch_template#tag-specifications
+	tagBlock, err := TagBlock(args)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings := []string{tagBlock}
+
+	// Tag the existing 'tag_specifications' blocks (including the content of dynamic blocks).
+	tagSpecificationsResult, err := TagNestedBlocks(args, []string{"tag_specifications"}, "tags", args.TagsFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings = append(swappedTagsStrings, tagSpecificationsResult.SwappedTagsStrings...)
+
+	resourceTypes, ok := getLaunchTemplateResourceTypes(args.Block)
+	if !ok {
+		log.Print("[WARN] Can't determine the tag_specifications resource types of ", args.Block.Labels(), ", not adding tag_specifications blocks.")
+
+		return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+	}
+
+	// Add 'tag_specifications' blocks for the resource types that have none.
+	for _, resourceType := range launchTemplateResourceTypes {
+		if slices.Contains(resourceTypes, resourceType) {
+			continue
+		}
+
+		tagSpecifications := args.Block.Body().AppendNewBlock("tag_specifications", nil)
+		convert.AppendTerratagAddedComment(tagSpecifications.Body())
+		tagSpecifications.Body().SetAttributeValue("resource_type", cty.StringVal(resourceType))
+
+		tagSpecificationsArgs := args
+		tagSpecificationsArgs.Block = tagSpecifications
+
+		tagBlock, err := TagBlock(tagSpecificationsArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		swappedTagsStrings = append(swappedTagsStrings, tagBlock)
+	}
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
+
+// getLaunchTemplateResourceTypes returns the resource types of the launch template 'tag_specifications' blocks.
+// The resource types of a dynamic block are known if its for_each is a literal list of resource types.
+func getLaunchTemplateResourceTypes(block *hclwrite.Block) ([]string, bool) {
+	var resourceTypes []string
+
+	for _, nestedBlock := range block.Body().Blocks() {
+		switch {
+		case nestedBlock.Type() == "tag_specifications":
+			resourceType, ok := getStringLiteral(nestedBlock.Body().GetAttribute("resource_type"))
+			if !ok {
+				return nil, false
+			}
+
+			resourceTypes = append(resourceTypes, resourceType)
+		case nestedBlock.Type() == "dynamic" && len(nestedBlock.Labels()) == 1 && nestedBlock.Labels()[0] == "tag_specifications":
+			dynamicResourceTypes, ok := getDynamicResourceTypes(nestedBlock)
+			if !ok {
+				return nil, false
+			}
+
+			resourceTypes = append(resourceTypes, dynamicResourceTypes...)
+		}
+	}
+
+	return resourceTypes, true
+}
+
+func getDynamicResourceTypes(dynamic *hclwrite.Block) ([]string, bool) {
+	content := dynamic.Body().FirstMatchingBlock("content", nil)
+	if content == nil || content.Body().GetAttribute("resource_type") == nil {
+		return nil, false
+	}
+
+	if resourceType, ok := getStringLiteral(content.Body().GetAttribute("resource_type")); ok {
+		return []string{resourceType}, true
+	}
+
+	iterator := getDynamicIterator(dynamic)
+
+	resourceTypeExpression := getAttributeExpression(content, "resource_type")
+	if resourceTypeExpression != iterator+".value" && resourceTypeExpression != iterator+".key" {
+		return nil, false
+	}
+
+	forEach := dynamic.Body().GetAttribute("for_each")
+	if forEach == nil {
+		return nil, false
+	}
+
+	expr, diags := hclsyntax.ParseExpression(forEach.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	// toset(["instance", "volume"])
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "toset" && len(call.Args) == 1 {
+		expr = call.Args[0]
+	}
+
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || !(value.Type().IsTupleType() || value.Type().IsListType()) {
+		return nil, false
+	}
+
+	var resourceTypes []string
+
+	for it := value.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		if element.IsNull() || element.Type() != cty.String {
+			return nil, false
+		}
+
+		resourceTypes = append(resourceTypes, element.AsString())
+	}
+
+	return resourceTypes, true
+}
+
+// getStringLiteral returns the value of a string literal attribute.
+func getStringLiteral(attribute *hclwrite.Attribute) (string, bool) {
+	if attribute == nil {
+		return "", false
+	}
+
+	expr, diags := hclsyntax.ParseExpression(attribute.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", false
+	}
+
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+
+	return value.AsString(), true
+}
+
+func tagEcsService(args TagBlockArgs) (*Result, error) {
+	// https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/ecs_service#propagate_tags
+	tagBlock, err := TagBlock(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Propagate the service tags to its tasks (unless the tasks get the tags of their task definition).
+	if args.Block.Body().GetAttribute("propagate_tags") == nil {
+		convert.AppendTerratagAddedComment(args.Block.Body())
+		args.Block.Body().SetAttributeValue("propagate_tags", cty.StringVal("SERVICE"))
+	}
+
+	return &Result{SwappedTagsStrings: []string{tagBlock}}, nil
+}
+
 func tagAutoscalingGroup(args TagBlockArgs) (*Result, error) {
 	// https://www.terraform.io/docs/providers/aws/r/autoscaling_group.html
 	var tagsMap map[string]string
@@ -94,12 +262,27 @@ func tagAutoscalingGroup(args TagBlockArgs) (*Result, error) {
 		expression = strings.TrimPrefix(expression, "${")
 		expression = strings.TrimSuffix(expression, "${")
 
-		key := "local." + tag_keys.GetTerratagAddedKey(args.Filename)
+		key := "local." + args.GetTerratagAddedKey()
 		newTagsValue := "flatten([" + key + "," + expression + "])"
 
 		newTags := ParseHclValueStringToTokens(newTagsValue)
 
 		args.Block.Body().SetAttributeRaw("tags", newTags)
+	} else if dynamicTag := getAutoscalingGroupDynamicTag(args.Block); dynamicTag != nil {
+		// a "dynamic" tag block iterating a tags map: the terratag tags are merged into its for_each
+		forEach := dynamicTag.Body().GetAttribute("for_each")
+		expression := strings.TrimSpace(string(forEach.Expr().BuildTokens(hclwrite.Tokens{}).Bytes()))
+
+		key := "local." + args.GetTerratagAddedKey()
+		newForEachValue := "merge(" + expression + ", " + key + ")"
+
+		if args.KeepExistingTags {
+			newForEachValue = "merge(" + key + ", " + expression + ")"
+		}
+
+		dynamicTag.Body().SetAttributeRaw("for_each", ParseHclValueStringToTokens(newForEachValue))
+
+		return &Result{SwappedTagsStrings: []string{newForEachValue}}, nil
 	} else {
 		// no "tags" interpolation is used, but rather multiple instances of a "tag" block
 		if err := convert.AppendTagBlocks(args.Block, args.Tags); err != nil {
@@ -109,3 +292,84 @@ func tagAutoscalingGroup(args TagBlockArgs) (*Result, error) {
 
 	return &Result{}, nil
 }
+
+// getAutoscalingGroupDynamicTag returns the "dynamic" tag block iterating a tags map (nil if there is none):
+//
+//	dynamic "tag" {
+//	  for_each = var.tags
+//	  content {
+//	    key                 = tag.key
+//	    value               = tag.value
+//	    propagate_at_launch = true
+//	  }
+//	}
+func getAutoscalingGroupDynamicTag(block *hclwrite.Block) *hclwrite.Block {
+	for _, dynamic := range block.Body().Blocks() {
+		if dynamic.Type() != "dynamic" || len(dynamic.Labels()) != 1 || dynamic.Labels()[0] != "tag" || dynamic.Body().GetAttribute("for_each") == nil {
+			continue
+		}
+
+		content := dynamic.Body().FirstMatchingBlock("content", nil)
+		if content == nil {
+			continue
+		}
+
+		iterator := getDynamicIterator(dynamic)
+
+		if getAttributeExpression(content, "key") == iterator+".key" && getAttributeExpression(content, "value") == iterator+".value" {
+			return dynamic
+		}
+	}
+
+	return nil
+}
+
+// getDynamicIterator returns the name of the iterator variable of a dynamic block.
+func getDynamicIterator(dynamic *hclwrite.Block) string {
+	if iterator := getAttributeExpression(dynamic, "iterator"); iterator != "" {
+		return iterator
+	}
+
+	return dynamic.Labels()[0]
+}
+
+func getAttributeExpression(block *hclwrite.Block, name string) string {
+	attribute := block.Body().GetAttribute(name)
+	if attribute == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(attribute.Expr().BuildTokens(hclwrite.Tokens{}).Bytes()))
+}
+
+// hasAutoscalingGroupTagBlocks checks that every tag has a matching "tag" block, or that the dynamic tag block
+// iterates the terratag locals (see tagAutoscalingGroup).
+func hasAutoscalingGroupTagBlocks(args TagBlockArgs) (bool, error) {
+	if dynamicTag := getAutoscalingGroupDynamicTag(args.Block); dynamicTag != nil {
+		return referencesTerratagLocals(dynamicTag.Body().GetAttribute("for_each").Expr().BuildTokens(hclwrite.Tokens{})), nil
+	}
+
+	var tagsMap map[string]string
+	if err := json.Unmarshal([]byte(args.Tags), &tagsMap); err != nil {
+		return false, err
+	}
+
+	keys := map[string]bool{}
+
+	for _, block := range args.Block.Body().Blocks() {
+		if block.Type() != "tag" || block.Body().GetAttribute("key") == nil {
+			continue
+		}
+
+		tokens := block.Body().GetAttribute("key").Expr().BuildTokens(hclwrite.Tokens{})
+		keys[strings.Trim(strings.TrimSpace(string(tokens.Bytes())), "\"")] = true
+	}
+
+	for key := range tagsMap {
+		if !keys[key] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -1,5 +1,11 @@
 package tagging
 
+import (
+	"log"
+
+	"github.com/env0/terratag/internal/convert"
+)
+
 func tagAksK8sCluster(args TagBlockArgs) (*Result, error) {
 	var swappedTagsStrings []string
 
@@ -26,3 +32,35 @@ func tagAksK8sCluster(args TagBlockArgs) (*Result, error) {
 
 	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
 }
+
+func tagAzapiResource(args TagBlockArgs) (*Result, error) {
+	// The tags argument is used, unless the tags are already set in the body (an object or jsonencode of an object).
+	// See: https://registry.terraform.io/providers/Azure/azapi/latest/docs/resources/resource#tags
+	if args.Block.Body().GetAttribute(args.TagId) != nil || !convert.HasBodyTags(args.Block) {
+		return defaultTaggingFn(args)
+	}
+
+	terratagAddedKey := "local." + args.GetTerratagAddedKey()
+
+	var newTagsValue string
+
+	isObject := convert.SetBodyTags(args.Block, func(existing string) string {
+		switch {
+		case existing == "":
+			newTagsValue = terratagAddedKey
+		case args.KeepExistingTags:
+			newTagsValue = "merge(" + terratagAddedKey + ", " + existing + ")"
+		default:
+			newTagsValue = "merge(" + existing + ", " + terratagAddedKey + ")"
+		}
+
+		return newTagsValue
+	})
+	if !isObject {
+		log.Print("[WARN] The body of ", args.Block.Labels(), " is not an object, its tags are not set.")
+
+		return &Result{}, nil
+	}
+
+	return &Result{SwappedTagsStrings: []string{newTagsValue}}, nil
+}
@@ -13,3 +13,82 @@ func tagContainerCluster(args TagBlockArgs) (*Result, error) {
 
 	return &Result{SwappedTagsStrings: rootBlockSwappedTagsStrings}, nil
 }
+
+func tagContainerNodePool(args TagBlockArgs) (*Result, error) {
+	// Label the node VMs (node_config.resource_labels, see providers.GetTagIdByResource) and the kubernetes nodes (node_config.labels).
+	// See: https://registry.terraform.io/providers/hashicorp/google/latest/docs/resources/container_node_pool#node_config
+	tagBlock, err := TagBlock(args)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings := []string{tagBlock}
+
+	nodeLabelsArgs := args
+	nodeLabelsArgs.TagId = "node_config.labels"
+
+	tagBlock, err = TagBlock(nodeLabelsArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings = append(swappedTagsStrings, tagBlock)
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
+
+func tagComputeInstance(args TagBlockArgs) (*Result, error) {
+	tagBlock, err := TagBlock(args)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings := []string{tagBlock}
+
+	// Label the boot disk created by the instance (a boot disk attached from an existing 'source' disk is labeled by its own resource).
+	// See: https://registry.terraform.io/providers/hashicorp/google/latest/docs/resources/compute_instance#initialize_params
+	bootDisk := args.Block.Body().FirstMatchingBlock("boot_disk", nil)
+	if bootDisk != nil && bootDisk.Body().GetAttribute("source") == nil {
+		bootDiskArgs := args
+		bootDiskArgs.Block = bootDisk
+		bootDiskArgs.TagId = "initialize_params.labels"
+
+		tagBlock, err := TagBlock(bootDiskArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		swappedTagsStrings = append(swappedTagsStrings, tagBlock)
+	}
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
+
+func tagComputeInstanceTemplate(args TagBlockArgs) (*Result, error) {
+	tagBlock, err := TagBlock(args)
+	if err != nil {
+		return nil, err
+	}
+
+	swappedTagsStrings := []string{tagBlock}
+
+	// Label the disks created from the template (not the existing 'source' disks).
+	// See: https://registry.terraform.io/providers/hashicorp/google/latest/docs/resources/compute_instance_template#labels-1
+	for _, disk := range findNestedBlocks(args.Block, []string{"disk"}) {
+		if disk.Body().GetAttribute("source") != nil {
+			continue
+		}
+
+		diskArgs := args
+		diskArgs.Block = disk
+
+		tagBlock, err := TagBlock(diskArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		swappedTagsStrings = append(swappedTagsStrings, tagBlock)
+	}
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
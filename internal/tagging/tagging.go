@@ -2,12 +2,16 @@ package tagging
 
 import (
 	"log"
+	"slices"
+	"strings"
 
 	"github.com/env0/terratag/internal/common"
 	"github.com/env0/terratag/internal/convert"
+	"github.com/env0/terratag/internal/providers"
 	"github.com/env0/terratag/internal/tag_keys"
 	"github.com/env0/terratag/internal/terraform"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
@@ -32,21 +36,41 @@ func ParseHclValueStringToTokens(hclValueString string) hclwrite.Tokens {
 	return tempAttribute.Expr().BuildTokens(hclwrite.Tokens{})
 }
 
+// TagBlock tags the TagId attribute of the block. A TagId path (e.g. metadata.labels) tags the attribute of
+// the nested blocks, which are created if missing.
 func TagBlock(args TagBlockArgs) (string, error) {
-	hasExistingTags, err := convert.MoveExistingTags(args.Filename, args.Terratag, args.Block, args.TagId)
+	// The existing tags of nested blocks are keyed by their resource too.
+	existingTagsKey := tag_keys.GetResourceExistingTagsKey(args.Filename, args.Block)
+
+	if path := strings.Split(args.TagId, "."); len(path) > 1 {
+		args.Block = getOrCreateNestedBlock(args.Block, path[:len(path)-1])
+		args.TagId = path[len(path)-1]
+	}
+
+	hasExistingTags, err := convert.MoveExistingTags(existingTagsKey, args.Terratag, args.Block, args.TagId)
 	if err != nil {
 		return "", err
 	}
 
-	terratagAddedKey := "local." + tag_keys.GetTerratagAddedKey(args.Filename)
+	terratagAddedKey := "local." + args.GetTerratagAddedKey()
 	newTagsValue := terratagAddedKey
 
+	switch args.TagsFormat {
+	case TagsKeyValueList:
+		newTagsValue = convert.GetKeyValueListExpression(terratagAddedKey)
+	case TagsKeyValueStrings:
+		newTagsValue = convert.GetKeyValueStringsExpression(terratagAddedKey)
+	}
+
 	if hasExistingTags {
-		existingTagsKey := tag_keys.GetResourceExistingTagsKey(args.Filename, args.Block)
 		existingTagsExpression := convert.GetExistingTagsExpression(args.Terratag.Found[existingTagsKey])
 
 		// Flip the order of arguments in merge based on KeepExistingTags flag
-		if args.KeepExistingTags {
+		if args.TagsFormat == TagsKeyValueList {
+			newTagsValue = convert.MergeKeyValueListExpression(existingTagsExpression, terratagAddedKey, args.KeepExistingTags)
+		} else if args.TagsFormat == TagsKeyValueStrings {
+			newTagsValue = convert.MergeKeyValueStringsExpression(existingTagsExpression, terratagAddedKey, args.KeepExistingTags)
+		} else if args.KeepExistingTags {
 			// Existing tags take precedence (come second in merge arguments)
 			newTagsValue = "merge( " + terratagAddedKey + ", " + existingTagsExpression + ")"
 		} else {
@@ -61,6 +85,181 @@ func TagBlock(args TagBlockArgs) (string, error) {
 	return newTagsValue, nil
 }
 
+// IsResourceTagged checks if the resource tags already reference the terratag locals.
+// Only the TagId attribute is checked (not the nested blocks tagged with -tag-nested-blocks).
+func IsResourceTagged(args TagBlockArgs) (bool, error) {
+	if terraform.GetResourceType(*args.Block) == "aws_autoscaling_group" && args.Block.Body().GetAttribute("tags") == nil {
+		return hasAutoscalingGroupTagBlocks(args)
+	}
+
+	if providers.IsAzapiResource(terraform.GetResourceType(*args.Block)) && args.Block.Body().GetAttribute("body") != nil &&
+		referencesTerratagLocals(args.Block.Body().GetAttribute("body").Expr().BuildTokens(hclwrite.Tokens{})) {
+		return true, nil
+	}
+
+	block := args.Block
+	path := strings.Split(args.TagId, ".")
+
+	for _, name := range path[:len(path)-1] {
+		if block = block.Body().FirstMatchingBlock(name, nil); block == nil {
+			return false, nil
+		}
+	}
+
+	tagsAttribute := block.Body().GetAttribute(path[len(path)-1])
+	if tagsAttribute == nil {
+		return false, nil
+	}
+
+	return referencesTerratagLocals(tagsAttribute.Expr().BuildTokens(hclwrite.Tokens{})), nil
+}
+
+func referencesTerratagLocals(tokens hclwrite.Tokens) bool {
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "local" &&
+			tokens[i+1].Type == hclsyntax.TokenDot &&
+			tokens[i+2].Type == hclsyntax.TokenIdent && tag_keys.IsTerratagAddedKey(string(tokens[i+2].Bytes)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TagProviderDefaults tags the default tags of a provider block.
+func TagProviderDefaults(args TagBlockArgs, defaultTags providers.DefaultTags) (*Result, error) {
+	if defaultTags.Block != "" {
+		block := args.Block.Body().FirstMatchingBlock(defaultTags.Block, nil)
+		if block == nil {
+			block = args.Block.Body().AppendNewBlock(defaultTags.Block, nil)
+		}
+
+		args.Block = block
+	}
+
+	args.TagId = defaultTags.Attribute
+
+	return defaultTaggingFn(args)
+}
+
+// IsProviderDefaultsTagged checks if the provider block default tags already reference the terratag locals.
+func IsProviderDefaultsTagged(block *hclwrite.Block, defaultTags providers.DefaultTags) bool {
+	if defaultTags.Block != "" {
+		if block = block.Body().FirstMatchingBlock(defaultTags.Block, nil); block == nil {
+			return false
+		}
+	}
+
+	tagsAttribute := block.Body().GetAttribute(defaultTags.Attribute)
+
+	return tagsAttribute != nil && referencesTerratagLocals(tagsAttribute.Expr().BuildTokens(hclwrite.Tokens{}))
+}
+
+// TagNestedBlocks tags the attribute of the existing nested blocks at the path (including the content of dynamic blocks).
+// Blocks already referencing the terratag locals (e.g. tagged by a resource tagging function) are skipped.
+func TagNestedBlocks(args TagBlockArgs, path []string, attribute string, tagsFormat TagsFormat) (*Result, error) {
+	var swappedTagsStrings []string
+
+	for _, block := range findNestedBlocks(args.Block, path) {
+		if tagsAttribute := block.Body().GetAttribute(attribute); tagsAttribute != nil &&
+			referencesTerratagLocals(tagsAttribute.Expr().BuildTokens(hclwrite.Tokens{})) {
+			continue
+		}
+
+		nestedArgs := args
+		nestedArgs.Block = block
+		nestedArgs.TagId = attribute
+		nestedArgs.TagsFormat = tagsFormat
+
+		tagBlock, err := TagBlock(nestedArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		swappedTagsStrings = append(swappedTagsStrings, tagBlock)
+	}
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
+
+func findNestedBlocks(block *hclwrite.Block, path []string) []*hclwrite.Block {
+	if len(path) == 0 {
+		return []*hclwrite.Block{block}
+	}
+
+	var blocks []*hclwrite.Block
+
+	for _, nestedBlock := range block.Body().Blocks() {
+		if nestedBlock.Type() == path[0] {
+			blocks = append(blocks, findNestedBlocks(nestedBlock, path[1:])...)
+		} else if nestedBlock.Type() == "dynamic" && len(nestedBlock.Labels()) == 1 && nestedBlock.Labels()[0] == path[0] {
+			if content := nestedBlock.Body().FirstMatchingBlock("content", nil); content != nil {
+				blocks = append(blocks, findNestedBlocks(content, path[1:])...)
+			}
+		}
+	}
+
+	return blocks
+}
+
+// PodTemplatePaths are the paths of the kubernetes workloads pod template blocks.
+var PodTemplatePaths = [][]string{
+	{"spec", "template"},
+	{"spec", "job_template", "spec", "template"},
+}
+
+// TagPodTemplates tags the metadata.labels of the pod template blocks at the path (see PodTemplatePaths).
+// The metadata blocks are created if missing. Templates already referencing the terratag locals are skipped.
+func TagPodTemplates(args TagBlockArgs, path []string) (*Result, error) {
+	var swappedTagsStrings []string
+
+	for _, template := range findNestedBlocks(args.Block, path) {
+		templateArgs := args
+		templateArgs.Block = getOrCreateNestedBlock(template, []string{"metadata"})
+
+		result, err := TagNestedBlocks(templateArgs, nil, "labels", TagsMap)
+		if err != nil {
+			return nil, err
+		}
+
+		swappedTagsStrings = append(swappedTagsStrings, result.SwappedTagsStrings...)
+	}
+
+	return &Result{SwappedTagsStrings: swappedTagsStrings}, nil
+}
+
+func getOrCreateNestedBlock(block *hclwrite.Block, path []string) *hclwrite.Block {
+	for _, name := range path {
+		nestedBlock := block.Body().FirstMatchingBlock(name, nil)
+		if nestedBlock == nil {
+			nestedBlock = block.Body().AppendNewBlock(name, nil)
+		}
+
+		block = nestedBlock
+	}
+
+	return block
+}
+
+// conflictingNestedTagAttributes are the nested tag attributes (by resource type and attribute) that can't be set together with
+// a resource attribute tagged by the resource tagging function.
+var conflictingNestedTagAttributes = map[string]map[string][]string{
+	// See: https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/instance#volume_tags
+	"aws_instance": {"volume_tags": {"root_block_device.tags", "ebs_block_device.tags"}},
+}
+
+// IsConflictingNestedTagAttribute checks if a nested tag attribute (e.g. root_block_device.tags) of a resource can't be tagged
+// along with the attributes chosen by its tagging function (e.g. the volume_tags of aws_instance).
+func IsConflictingNestedTagAttribute(resource *hclwrite.Block, nestedTagAttribute string) bool {
+	for attribute, nestedTagAttributes := range conflictingNestedTagAttributes[terraform.GetResourceType(*resource)] {
+		if resource.Body().GetAttribute(attribute) != nil && slices.Contains(nestedTagAttributes, nestedTagAttribute) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func HasResourceTagFn(resourceType string) bool {
 	return resourceTypeToFnMap[resourceType] != nil
 }
@@ -78,10 +277,17 @@ func TagResource(args TagBlockArgs) (*Result, error) {
 }
 
 var resourceTypeToFnMap = map[string]TagResourceFn{
-	"aws_autoscaling_group":      tagAutoscalingGroup,
-	"aws_instance":               tagAwsInstance,
-	"google_container_cluster":   tagContainerCluster,
-	"azurerm_kubernetes_cluster": tagAksK8sCluster,
+	"aws_autoscaling_group":                   tagAutoscalingGroup,
+	"aws_ecs_service":                         tagEcsService,
+	"aws_instance":                            tagAwsInstance,
+	"aws_launch_template":                     tagAwsLaunchTemplate,
+	"azapi_resource":                          tagAzapiResource,
+	"google_compute_instance":                 tagComputeInstance,
+	"google_compute_instance_template":        tagComputeInstanceTemplate,
+	"google_compute_region_instance_template": tagComputeInstanceTemplate,
+	"google_container_cluster":                tagContainerCluster,
+	"google_container_node_pool":              tagContainerNodePool,
+	"azurerm_kubernetes_cluster":              tagAksK8sCluster,
 }
 
 type TagBlockArgs struct {
@@ -91,6 +297,27 @@ type TagBlockArgs struct {
 	Terratag         common.TerratagLocal
 	TagId            string
 	KeepExistingTags bool
+	// TagSet is the name of the resource additional tag set (empty for the default tags).
+	TagSet string
+	// TagsFormat is the shape of the TagId attribute (a map by default).
+	TagsFormat TagsFormat
+}
+
+// TagsFormat is the shape of a tags attribute (based on its type in the provider schema).
+type TagsFormat string
+
+const (
+	// TagsMap - map(string).
+	TagsMap TagsFormat = "map"
+	// TagsKeyValueList - a list (or set) of {key, value} objects.
+	TagsKeyValueList TagsFormat = "key_value_list"
+	// TagsKeyValueStrings - a list (or set) of "key:value" strings.
+	TagsKeyValueStrings TagsFormat = "key_value_strings"
+)
+
+// GetTerratagAddedKey returns the name of the locals with the resource tags.
+func (args TagBlockArgs) GetTerratagAddedKey() string {
+	return tag_keys.GetTerratagAddedSetKey(args.Filename, args.TagSet)
 }
 
 type TagResourceFn func(args TagBlockArgs) (*Result, error)
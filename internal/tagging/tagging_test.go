@@ -1,9 +1,13 @@
 package tagging
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/env0/terratag/internal/common"
+	"github.com/env0/terratag/internal/convert"
+	"github.com/env0/terratag/internal/providers"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/stretchr/testify/assert"
 )
@@ -49,7 +53,6 @@ func TestTagBlock_MergeOrder(t *testing.T) {
 				Found: map[string]hclwrite.Tokens{
 					"terratag_existing_tags_main_resource_aws_s3_bucket_test": existingTagsToken,
 				},
-				Added: `{"Name"="Terratag Name","Owner"="DevOps"}`,
 			}
 
 			args := TagBlockArgs{
@@ -69,3 +72,653 @@ func TestTagBlock_MergeOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestIsResourceTagged(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tags     string
+		expected bool
+	}{
+		{name: "No tags", tags: "", expected: false},
+		{name: "Literal tags", tags: `{ Name = "bucket" }`, expected: false},
+		{name: "Terratag locals", tags: `local.terratag_added_main`, expected: true},
+		{name: "Merged terratag locals", tags: `merge({ "Name" = "bucket" }, local.terratag_added_main)`, expected: true},
+		{name: "Other locals", tags: `local.common_tags`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := hclwrite.NewEmptyFile()
+			resourceBlock := f.Body().AppendNewBlock("resource", []string{"aws_s3_bucket", "test"})
+
+			if tc.tags != "" {
+				resourceBlock.Body().SetAttributeRaw("tags", ParseHclValueStringToTokens(tc.tags))
+			}
+
+			isTagged, err := IsResourceTagged(TagBlockArgs{
+				Filename: "main",
+				Block:    resourceBlock,
+				Tags:     `{"Owner": "DevOps"}`,
+				TagId:    "tags",
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, isTagged)
+		})
+	}
+}
+
+func TestTagProviderDefaults(t *testing.T) {
+	f := hclwrite.NewEmptyFile()
+	providerBlock := f.Body().AppendNewBlock("provider", []string{"aws"})
+	defaultTags := providers.DefaultTags{Block: "default_tags", Attribute: "tags"}
+
+	args := TagBlockArgs{
+		Filename: "main",
+		Block:    providerBlock,
+		Tags:     `{"Owner": "DevOps"}`,
+		Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+	}
+
+	assert.False(t, IsProviderDefaultsTagged(providerBlock, defaultTags))
+
+	result, err := TagProviderDefaults(args, defaultTags)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"local.terratag_added_main"}, result.SwappedTagsStrings)
+	assert.True(t, IsProviderDefaultsTagged(providerBlock, defaultTags))
+	assert.Equal(t, "provider \"aws\" {\n  default_tags {\n    tags = local.terratag_added_main\n  }\n}\n", string(f.Bytes()))
+}
+
+func TestTagNestedBlocks(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "aws_launch_template" "lt" {
+  tag_specifications {
+    resource_type = "instance"
+  }
+  tag_specifications {
+    resource_type = "volume"
+    tags          = local.terratag_added_main
+  }
+  dynamic "tag_specifications" {
+    for_each = ["network-interface"]
+    content {
+      resource_type = tag_specifications.value
+    }
+  }
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	args := TagBlockArgs{
+		Filename: "main",
+		Block:    f.Body().Blocks()[0],
+		Tags:     `{"Owner": "DevOps"}`,
+		Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+	}
+
+	result, err := TagNestedBlocks(args, []string{"tag_specifications"}, "tags", TagsMap)
+	assert.NoError(t, err)
+	// The already tagged block is skipped.
+	assert.Len(t, result.SwappedTagsStrings, 2)
+
+	for _, block := range findNestedBlocks(args.Block, []string{"tag_specifications"}) {
+		assert.Equal(t, "local.terratag_added_main", strings.TrimSpace(string(block.Body().GetAttribute("tags").Expr().BuildTokens(nil).Bytes())))
+	}
+
+	result, err = TagNestedBlocks(args, []string{"missing"}, "tags", TagsMap)
+	assert.NoError(t, err)
+	assert.Empty(t, result.SwappedTagsStrings)
+}
+
+func TestIsConflictingNestedTagAttribute(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "aws_instance" "volume_tags" {
+  volume_tags = {}
+}
+resource "aws_instance" "block_tags" {
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	blocks := f.Body().Blocks()
+
+	assert.True(t, IsConflictingNestedTagAttribute(blocks[0], "root_block_device.tags"))
+	assert.True(t, IsConflictingNestedTagAttribute(blocks[0], "ebs_block_device.tags"))
+	assert.False(t, IsConflictingNestedTagAttribute(blocks[0], "network_interface.tags"))
+	assert.False(t, IsConflictingNestedTagAttribute(blocks[1], "root_block_device.tags"))
+}
+
+func TestTagBlock_KeyValueList(t *testing.T) {
+	testCases := []struct {
+		name             string
+		tags             string
+		keepExistingTags bool
+		expected         string
+	}{
+		{
+			name:     "No existing tags",
+			expected: `[for k, v in local.terratag_added_main : { key = k, value = v }]`,
+		},
+		{
+			name:     "Existing tags - new tags override existing",
+			tags:     `[{ key = "Name", value = "b" }]`,
+			expected: `concat([for tag in [{ key = "Name", value = "b" }] : tag if !contains(keys(local.terratag_added_main), tag.key)], [for k, v in local.terratag_added_main : { key = k, value = v }])`,
+		},
+		{
+			name:             "Existing tags - keep existing tags",
+			tags:             `var.tags`,
+			keepExistingTags: true,
+			expected:         `concat(var.tags, [for k, v in local.terratag_added_main : { key = k, value = v } if !contains([for tag in var.tags : tag.key], k)])`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := "resource \"awscc_s3_bucket\" \"b\" {\n"
+			if tc.tags != "" {
+				src += "  tags = " + tc.tags + "\n"
+			}
+
+			src += "}\n"
+
+			f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+			assert.False(t, diags.HasErrors())
+
+			args := TagBlockArgs{
+				Filename:         "main",
+				Block:            f.Body().Blocks()[0],
+				Tags:             `{"Owner": "DevOps"}`,
+				Terratag:         common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+				TagId:            "tags",
+				KeepExistingTags: tc.keepExistingTags,
+				TagsFormat:       TagsKeyValueList,
+			}
+
+			result, err := TagBlock(args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+
+			// The terratag references can be reverted.
+			modified, err := convert.RemoveTerratagTags(f)
+			assert.NoError(t, err)
+			assert.True(t, modified)
+
+			expected, _ := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+			assert.Equal(t, string(hclwrite.Format(expected.Bytes())), string(hclwrite.Format(f.Bytes())))
+		})
+	}
+}
+
+func TestTagBlock_KeyValueStrings(t *testing.T) {
+	testCases := []struct {
+		name             string
+		tags             string
+		keepExistingTags bool
+		expected         string
+	}{
+		{
+			name:     "No existing tags",
+			expected: `[for k, v in local.terratag_added_main : "${k}:${v}"]`,
+		},
+		{
+			name:     "Existing tags - new tags override existing",
+			tags:     `["env:dev", "team:${var.team}"]`,
+			expected: `concat([for tag in ["env:dev", "team:${var.team}"] : tag if !contains(keys(local.terratag_added_main), split(":", tag)[0])], [for k, v in local.terratag_added_main : "${k}:${v}"])`,
+		},
+		{
+			name:             "Existing tags - keep existing tags",
+			tags:             `var.tags`,
+			keepExistingTags: true,
+			expected:         `concat(var.tags, [for k, v in local.terratag_added_main : "${k}:${v}" if !contains([for tag in var.tags : split(":", tag)[0]], k)])`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := "resource \"datadog_monitor\" \"m\" {\n"
+			if tc.tags != "" {
+				src += "  tags = " + tc.tags + "\n"
+			}
+
+			src += "}\n"
+
+			f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+			assert.False(t, diags.HasErrors())
+
+			args := TagBlockArgs{
+				Filename:         "main",
+				Block:            f.Body().Blocks()[0],
+				Tags:             `{"Owner": "DevOps"}`,
+				Terratag:         common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+				TagId:            "tags",
+				KeepExistingTags: tc.keepExistingTags,
+				TagsFormat:       TagsKeyValueStrings,
+			}
+
+			result, err := TagBlock(args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+
+			// The terratag references can be reverted.
+			modified, err := convert.RemoveTerratagTags(f)
+			assert.NoError(t, err)
+			assert.True(t, modified)
+
+			expected, _ := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+			assert.Equal(t, string(hclwrite.Format(expected.Bytes())), string(hclwrite.Format(f.Bytes())))
+		})
+	}
+}
+
+func TestTagBlock_NestedAttribute(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "kubernetes_namespace" "ns" {
+  metadata {
+    name = "ns"
+  }
+}
+resource "kubernetes_secret" "s" {
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	for _, block := range f.Body().Blocks() {
+		args := TagBlockArgs{
+			Filename: "main",
+			Block:    block,
+			Tags:     `{"Owner": "DevOps"}`,
+			Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+			TagId:    "metadata.labels",
+		}
+
+		isTagged, err := IsResourceTagged(args)
+		assert.NoError(t, err)
+		assert.False(t, isTagged)
+
+		_, err = TagBlock(args)
+		assert.NoError(t, err)
+
+		isTagged, err = IsResourceTagged(args)
+		assert.NoError(t, err)
+		assert.True(t, isTagged)
+	}
+
+	assert.Equal(t, `resource "kubernetes_namespace" "ns" {
+  metadata {
+    name   = "ns"
+    labels = local.terratag_added_main
+  }
+}
+resource "kubernetes_secret" "s" {
+  metadata {
+    labels = local.terratag_added_main
+  }
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
+
+func TestTagPodTemplates(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "kubernetes_deployment" "d" {
+  metadata {
+    name = "d"
+  }
+  spec {
+    template {
+      spec {
+        container {
+          name = "app"
+        }
+      }
+    }
+  }
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	args := TagBlockArgs{
+		Filename: "main",
+		Block:    f.Body().Blocks()[0],
+		Tags:     `{"Owner": "DevOps"}`,
+		Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+		TagId:    "metadata.labels",
+	}
+
+	result, err := TagPodTemplates(args, PodTemplatePaths[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"local.terratag_added_main"}, result.SwappedTagsStrings)
+
+	// Already tagged templates are skipped.
+	result, err = TagPodTemplates(args, PodTemplatePaths[0])
+	assert.NoError(t, err)
+	assert.Empty(t, result.SwappedTagsStrings)
+
+	result, err = TagPodTemplates(args, PodTemplatePaths[1])
+	assert.NoError(t, err)
+	assert.Empty(t, result.SwappedTagsStrings)
+
+	template := findNestedBlocks(args.Block, PodTemplatePaths[0])[0]
+	assert.Equal(t, "local.terratag_added_main", strings.TrimSpace(string(template.Body().FirstMatchingBlock("metadata", nil).Body().GetAttribute("labels").Expr().BuildTokens(nil).Bytes())))
+}
+
+func TestTagAwsLaunchTemplate(t *testing.T) {
+	testCases := []struct {
+		name                string
+		tagSpecifications   string
+		expectedTagged      int
+		expectedAddedBlocks []string
+	}{
+		{
+			name:                "No tag specifications",
+			expectedTagged:      4,
+			expectedAddedBlocks: []string{"instance", "volume", "network-interface"},
+		},
+		{
+			name: "Existing tag specifications",
+			tagSpecifications: `  tag_specifications {
+    resource_type = "instance"
+    tags          = { Name = "i" }
+  }
+`,
+			expectedTagged:      4,
+			expectedAddedBlocks: []string{"volume", "network-interface"},
+		},
+		{
+			name: "Dynamic tag specifications",
+			tagSpecifications: `  dynamic "tag_specifications" {
+    for_each = toset(["instance", "volume"])
+    content {
+      resource_type = tag_specifications.value
+    }
+  }
+`,
+			expectedTagged:      3,
+			expectedAddedBlocks: []string{"network-interface"},
+		},
+		{
+			name: "Unknown dynamic tag specifications",
+			tagSpecifications: `  dynamic "tag_specifications" {
+    for_each = var.resource_types
+    content {
+      resource_type = tag_specifications.value
+    }
+  }
+`,
+			expectedTagged: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, diags := hclwrite.ParseConfig([]byte("resource \"aws_launch_template\" \"lt\" {\n"+tc.tagSpecifications+"}\n"), "main.tf", hcl.InitialPos)
+			assert.False(t, diags.HasErrors())
+
+			args := TagBlockArgs{
+				Filename: "main",
+				Block:    f.Body().Blocks()[0],
+				Tags:     `{"Owner": "DevOps"}`,
+				Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+				TagId:    "tags",
+			}
+
+			result, err := TagResource(args)
+			assert.NoError(t, err)
+			assert.Len(t, result.SwappedTagsStrings, tc.expectedTagged)
+
+			var addedBlocks []string
+
+			for _, block := range findNestedBlocks(args.Block, []string{"tag_specifications"}) {
+				assert.True(t, referencesTerratagLocals(block.Body().GetAttribute("tags").Expr().BuildTokens(nil)))
+
+				resourceType, ok := getStringLiteral(block.Body().GetAttribute("resource_type"))
+				isAdded := ok && !strings.Contains(tc.tagSpecifications, `"`+resourceType+`"`)
+
+				// The added blocks are marked, so they are removed when reverting.
+				assert.Equal(t, isAdded, strings.Contains(string(block.Body().BuildTokens(nil).Bytes()), "# Added by terratag"))
+
+				if isAdded {
+					addedBlocks = append(addedBlocks, resourceType)
+				}
+			}
+
+			assert.Equal(t, tc.expectedAddedBlocks, addedBlocks)
+		})
+	}
+}
+
+func TestTagAutoscalingGroup_DynamicTag(t *testing.T) {
+	testCases := []struct {
+		name             string
+		keepExistingTags bool
+		expected         string
+	}{
+		{name: "New tags override existing", expected: "merge(var.tags, local.terratag_added_main)"},
+		{name: "Keep existing tags", keepExistingTags: true, expected: "merge(local.terratag_added_main, var.tags)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, diags := hclwrite.ParseConfig([]byte(`resource "aws_autoscaling_group" "asg" {
+  dynamic "tag" {
+    for_each = var.tags
+    iterator = t
+    content {
+      key                 = t.key
+      value               = t.value
+      propagate_at_launch = true
+    }
+  }
+}
+`), "main.tf", hcl.InitialPos)
+			assert.False(t, diags.HasErrors())
+
+			args := TagBlockArgs{
+				Filename:         "main",
+				Block:            f.Body().Blocks()[0],
+				Tags:             `{"Owner": "DevOps"}`,
+				Terratag:         common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+				TagId:            "tags",
+				KeepExistingTags: tc.keepExistingTags,
+			}
+
+			isTagged, err := IsResourceTagged(args)
+			assert.NoError(t, err)
+			assert.False(t, isTagged)
+
+			result, err := TagResource(args)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{tc.expected}, result.SwappedTagsStrings)
+			// No static "tag" blocks are added.
+			assert.Len(t, args.Block.Body().Blocks(), 1)
+
+			isTagged, err = IsResourceTagged(args)
+			assert.NoError(t, err)
+			assert.True(t, isTagged)
+		})
+	}
+}
+
+func TestTagEcsService(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "aws_ecs_service" "a" {
+}
+resource "aws_ecs_service" "b" {
+  propagate_tags = "TASK_DEFINITION"
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	for _, block := range f.Body().Blocks() {
+		_, err := TagResource(TagBlockArgs{
+			Filename: "main",
+			Block:    block,
+			Tags:     `{"Owner": "DevOps"}`,
+			Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+			TagId:    "tags",
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, `resource "aws_ecs_service" "a" {
+  tags = local.terratag_added_main
+  # Added by terratag
+  propagate_tags = "SERVICE"
+}
+resource "aws_ecs_service" "b" {
+  propagate_tags = "TASK_DEFINITION"
+  tags           = local.terratag_added_main
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	// The added propagate_tags is removed when reverting.
+	f, diags = hclwrite.ParseConfig(hclwrite.Format(f.Bytes()), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	modified, err := convert.RemoveTerratagTags(f)
+	assert.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, `resource "aws_ecs_service" "a" {
+}
+resource "aws_ecs_service" "b" {
+  propagate_tags = "TASK_DEFINITION"
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
+
+func TestTagGcpDisks(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "google_compute_instance" "image" {
+  boot_disk {
+  }
+}
+resource "google_compute_instance" "source" {
+  boot_disk {
+    source = "disk"
+  }
+}
+resource "google_compute_instance_template" "template" {
+  disk {
+  }
+  disk {
+    source = "disk"
+  }
+}
+resource "google_container_node_pool" "pool" {
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	for _, block := range f.Body().Blocks() {
+		_, err := TagResource(TagBlockArgs{
+			Filename: "main",
+			Block:    block,
+			Tags:     `{"Owner": "DevOps"}`,
+			Terratag: common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+			TagId:    providers.GetTagIdByResource(block.Labels()[0]),
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, `resource "google_compute_instance" "image" {
+  boot_disk {
+    initialize_params {
+      labels = local.terratag_added_main
+    }
+  }
+  labels = local.terratag_added_main
+}
+resource "google_compute_instance" "source" {
+  boot_disk {
+    source = "disk"
+  }
+  labels = local.terratag_added_main
+}
+resource "google_compute_instance_template" "template" {
+  disk {
+    labels = local.terratag_added_main
+  }
+  disk {
+    source = "disk"
+  }
+  labels = local.terratag_added_main
+}
+resource "google_container_node_pool" "pool" {
+  node_config {
+    resource_labels = local.terratag_added_main
+    labels          = local.terratag_added_main
+  }
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
+
+func TestTagAzapiResource(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "azapi_resource" "object" {
+  body = {
+    sku  = "Standard"
+    tags = { "Name" = "a" }
+  }
+}
+resource "azapi_resource" "jsonencode" {
+  body = jsonencode({
+    tags = var.tags
+  })
+}
+resource "azapi_resource" "no_body_tags" {
+  body = {
+    sku = "Standard"
+  }
+}
+`), "main.tf", hcl.InitialPos)
+	assert.False(t, diags.HasErrors())
+
+	for _, block := range f.Body().Blocks() {
+		args := TagBlockArgs{
+			Filename:         "main",
+			Block:            block,
+			Tags:             `{"Owner": "DevOps"}`,
+			Terratag:         common.TerratagLocal{Found: map[string]hclwrite.Tokens{}},
+			TagId:            "tags",
+			KeepExistingTags: block.Labels()[1] == "jsonencode",
+		}
+
+		_, err := TagResource(args)
+		assert.NoError(t, err)
+
+		isTagged, err := IsResourceTagged(args)
+		assert.NoError(t, err)
+		assert.True(t, isTagged)
+	}
+
+	assert.Equal(t, `resource "azapi_resource" "object" {
+  body = {
+    sku  = "Standard"
+    tags = merge({ "Name" = "a" }, local.terratag_added_main)
+  }
+}
+resource "azapi_resource" "jsonencode" {
+  body = jsonencode({
+    tags = merge(local.terratag_added_main, var.tags)
+  })
+}
+resource "azapi_resource" "no_body_tags" {
+  body = {
+    sku = "Standard"
+  }
+  tags = local.terratag_added_main
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	modified, err := convert.RemoveTerratagTags(f)
+	assert.NoError(t, err)
+	assert.True(t, modified)
+	assert.Equal(t, `resource "azapi_resource" "object" {
+  body = {
+    sku  = "Standard"
+    tags = { "Name" = "a" }
+  }
+}
+resource "azapi_resource" "jsonencode" {
+  body = jsonencode({
+    tags = var.tags
+  })
+}
+resource "azapi_resource" "no_body_tags" {
+  body = {
+    sku = "Standard"
+  }
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
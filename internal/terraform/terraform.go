@@ -12,8 +12,11 @@ import (
 
 	"github.com/bmatcuk/doublestar"
 	"github.com/env0/terratag/internal/common"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/thoas/go-funk"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func GetResourceType(resource hclwrite.Block) string {
@@ -162,3 +165,141 @@ type ModuleMetadata struct {
 	Source string `json:"Source"`
 	Dir    string `json:"Dir"`
 }
+
+const LockFilename = ".terraform.lock.hcl"
+
+// LockedProvider is a provider selection recorded in the dependency lock file.
+type LockedProvider struct {
+	// Address is the provider source address (e.g. registry.terraform.io/hashicorp/aws).
+	Address string
+	Version string
+	Hashes  []string
+}
+
+// GetLockedProviders returns the providers recorded in the dependency lock file of a directory (by address).
+// An empty map is returned if there's no lock file.
+func GetLockedProviders(dir string) (map[string]*LockedProvider, error) {
+	lockedProviders := map[string]*LockedProvider{}
+
+	src, err := os.ReadFile(filepath.Join(dir, LockFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lockedProviders, nil
+		}
+
+		return nil, err
+	}
+
+	lockFile, diags := hclsyntax.ParseConfig(src, LockFilename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFilename, diags)
+	}
+
+	for _, block := range lockFile.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+
+		lockedProvider := &LockedProvider{Address: block.Labels[0]}
+
+		if attribute, ok := block.Body.Attributes["version"]; ok {
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() || !value.Type().Equals(cty.String) {
+				return nil, fmt.Errorf("invalid version of provider %s in %s", lockedProvider.Address, LockFilename)
+			}
+
+			lockedProvider.Version = value.AsString()
+		}
+
+		if attribute, ok := block.Body.Attributes["hashes"]; ok {
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() || !value.CanIterateElements() {
+				return nil, fmt.Errorf("invalid hashes of provider %s in %s", lockedProvider.Address, LockFilename)
+			}
+
+			for it := value.ElementIterator(); it.Next(); {
+				_, hash := it.Element()
+				if hash.Type().Equals(cty.String) {
+					lockedProvider.Hashes = append(lockedProvider.Hashes, hash.AsString())
+				}
+			}
+		}
+
+		lockedProviders[lockedProvider.Address] = lockedProvider
+	}
+
+	return lockedProviders, nil
+}
+
+// GetLockedProviderVersion returns the locked version of a provider by its type name (e.g. google), or an empty string if not found.
+func GetLockedProviderVersion(lockedProviders map[string]*LockedProvider, name string) string {
+	for address, lockedProvider := range lockedProviders {
+		if strings.HasSuffix(address, "/"+name) {
+			return lockedProvider.Version
+		}
+	}
+
+	return ""
+}
+
+// GetStringReferences returns the string literal locals and variable defaults of the directory configuration files,
+// by reference (e.g. local.name and var.name). Terratag files are only read when their original file isn't next to them
+// (renamed to a backup). Invalid files are skipped.
+func GetStringReferences(dir string) (map[string]string, error) {
+	references := map[string]string{}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if originalPath, ok := strings.CutSuffix(path, ".terratag.tf"); ok {
+			if _, err := os.Stat(originalPath + ".tf"); err == nil {
+				continue
+			}
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[WARN] skipping %s due to an error: %v", path, err)
+
+			continue
+		}
+
+		configFile, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			log.Printf("[WARN] skipping %s due to an error: %v", path, diags)
+
+			continue
+		}
+
+		for _, block := range configFile.Body.(*hclsyntax.Body).Blocks {
+			switch {
+			case block.Type == "locals":
+				for name, attribute := range block.Body.Attributes {
+					if value, ok := getStringLiteral(attribute.Expr); ok {
+						references["local."+name] = value
+					}
+				}
+			case block.Type == "variable" && len(block.Labels) == 1:
+				if attribute, ok := block.Body.Attributes["default"]; ok {
+					if value, ok := getStringLiteral(attribute.Expr); ok {
+						references["var."+block.Labels[0]] = value
+					}
+				}
+			}
+		}
+	}
+
+	return references, nil
+}
+
+func getStringLiteral(expr hclsyntax.Expression) (string, bool) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || !value.Type().Equals(cty.String) {
+		return "", false
+	}
+
+	return value.AsString(), true
+}
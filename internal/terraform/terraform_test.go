@@ -0,0 +1,37 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStringReferences(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		// Tagged in rename mode (the original file is main.tf.bak).
+		"main.terratag.tf": "locals {\n  storage_type = \"Microsoft.Storage/storageAccounts@2023-01-01\"\n}\n",
+		"main.tf.bak":      "locals {\n  storage_type = \"Microsoft.Storage/storageAccounts@2021-01-01\"\n}\n",
+		// Tagged in place, along with a stale terratag file.
+		"variables.tf":          "variable \"registry_type\" {\n  default = \"Microsoft.ContainerRegistry/registries@2020-11-01-preview\"\n}\n",
+		"variables.terratag.tf": "variable \"registry_type\" {\n  default = \"stale\"\n}\n",
+		"invalid.tf":            "locals {\n",
+		"other.tf":              "locals {\n  name = \"name\"\n  count = 1\n}\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	references, err := GetStringReferences(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"local.storage_type": "Microsoft.Storage/storageAccounts@2023-01-01",
+		"var.registry_type":  "Microsoft.ContainerRegistry/registries@2020-11-01-preview",
+		"local.name":         "name",
+	}, references)
+}
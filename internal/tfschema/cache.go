@@ -0,0 +1,102 @@
+package tfschema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/env0/terratag/internal/terraform"
+)
+
+// DefaultCacheDir returns the default provider schemas cache directory (an empty string if there's no user cache directory).
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "terratag", "schemas")
+}
+
+// getCacheKey returns the provider schemas cache key of a directory.
+// The key is derived from the provider addresses, versions and hashes in the dependency lock file.
+// An empty key is returned if there are no locked providers.
+func getCacheKey(dir string) (string, error) {
+	lockedProviders, err := terraform.GetLockedProviders(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if len(lockedProviders) == 0 {
+		return "", nil
+	}
+
+	lines := make([]string, 0, len(lockedProviders))
+
+	for _, lockedProvider := range lockedProviders {
+		hashes := append([]string{}, lockedProvider.Hashes...)
+		sort.Strings(hashes)
+
+		lines = append(lines, fmt.Sprintf("%s %s %s", lockedProvider.Address, lockedProvider.Version, strings.Join(hashes, ",")))
+	}
+
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readCache loads the cached provider schemas. Returns nil if not cached.
+func readCache(path string) *ProviderSchemas {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	providerSchemas := &ProviderSchemas{
+		ProviderSchemas: make(map[string]*ProviderSchema),
+	}
+
+	if err := decodeProviderSchemasFile(path, providerSchemas); err != nil {
+		log.Printf("[WARN] Ignoring invalid provider schemas cache file: %v", err)
+
+		return nil
+	}
+
+	return providerSchemas
+}
+
+// writeCache stores the provider schemas documents (see ExportProviderSchemas) in the cache.
+func writeCache(path string, documents [][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent runs never read a partial cache file.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		if _, err := f.Write(append(document, '\n')); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
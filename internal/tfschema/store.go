@@ -0,0 +1,86 @@
+package tfschema
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/env0/terratag/internal/common"
+)
+
+var ErrNoProviderSchemas = errors.New("no provider schemas available")
+
+type StoreOptions struct {
+	IACType            common.IACType
+	DefaultToTerraform bool
+	// CacheDir is the on-disk provider schemas cache directory (disabled if empty).
+	CacheDir string
+	// SchemaFile is a file (or a directory of *.json files) of pre-exported provider schemas.
+	// If set, it's used for all directories instead of running the IaC binary.
+	SchemaFile string
+}
+
+type storeEntry struct {
+	once            sync.Once
+	providerSchemas *ProviderSchemas
+	err             error
+}
+
+// Store holds the provider schemas of root directories, loaded on demand (once per directory).
+// It is safe for concurrent use.
+type Store struct {
+	lock    sync.Mutex
+	entries map[string]*storeEntry
+	load    func(dir string) (*ProviderSchemas, error)
+	// shared is set when the schemas are the same for all directories (loaded once).
+	shared bool
+}
+
+func NewStore(options StoreOptions) *Store {
+	store := &Store{entries: map[string]*storeEntry{}}
+
+	if options.SchemaFile != "" {
+		store.shared = true
+		store.load = func(string) (*ProviderSchemas, error) {
+			return readProviderSchemasFile(options.SchemaFile)
+		}
+	} else {
+		store.load = func(dir string) (*ProviderSchemas, error) {
+			return loadProviderSchemas(dir, options.IACType, options.DefaultToTerraform, options.CacheDir)
+		}
+	}
+
+	return store
+}
+
+// Get returns the provider schemas of a root directory, loading them if required.
+// A failure to load is returned (wrapping ErrNoProviderSchemas) for every call with the same directory.
+func (s *Store) Get(dir string) (*ProviderSchemas, error) {
+	key := dir
+	if s.shared {
+		key = ""
+	}
+
+	s.lock.Lock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &storeEntry{}
+		s.entries[key] = entry
+	}
+
+	s.lock.Unlock()
+
+	entry.once.Do(func() {
+		providerSchemas, err := s.load(dir)
+		if err != nil {
+			entry.err = fmt.Errorf("%w for directory %s: %w", ErrNoProviderSchemas, dir, err)
+
+			return
+		}
+
+		entry.providerSchemas = providerSchemas
+	})
+
+	return entry.providerSchemas, entry.err
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/env0/terratag/internal/common"
@@ -23,22 +27,83 @@ import (
 
 var ErrResourceTypeNotFound = errors.New("resource type not found")
 
-var providerSchemasMap map[string]*ProviderSchemas = map[string]*ProviderSchemas{}
-
 var customSupportedProviderNames = [...]string{"google-beta"}
 
 type Attribute struct {
-	Type      cty.Type `json:"type"`
-	Required  bool     `json:"required"`
-	Optional  bool     `json:"optional"`
-	Computed  bool     `json:"computed"`
-	Sensitive bool     `json:"sensitive"`
+	Type cty.Type `json:"type"`
+	// NestedType is set (instead of Type) for nested attributes (e.g. of plugin framework providers like awscc).
+	NestedType *NestedType `json:"nested_type"`
+	Required   bool        `json:"required"`
+	Optional   bool        `json:"optional"`
+	Computed   bool        `json:"computed"`
+	Sensitive  bool        `json:"sensitive"`
+}
+
+type NestedType struct {
+	Attributes  map[string]*Attribute `json:"attributes"`
+	NestingMode string                `json:"nesting_mode"`
+}
+
+// GetTagsFormat returns the shape of a tags attribute: a map(string) or a list (or set) of {key, value} objects.
+// Returns false for any other type.
+func (a *Attribute) GetTagsFormat() (tagging.TagsFormat, bool) {
+	if a.NestedType != nil {
+		_, hasKey := a.NestedType.Attributes["key"]
+		_, hasValue := a.NestedType.Attributes["value"]
+
+		if (a.NestedType.NestingMode == "list" || a.NestedType.NestingMode == "set") && hasKey && hasValue {
+			return tagging.TagsKeyValueList, true
+		}
+
+		return "", false
+	}
+
+	switch {
+	// No type (older schemas) or a dynamic type is assumed to be a map.
+	case a.Type == cty.NilType, a.Type == cty.DynamicPseudoType, a.Type.IsMapType():
+		return tagging.TagsMap, true
+	case a.Type.IsListType() || a.Type.IsSetType():
+		elementType := a.Type.ElementType()
+		if elementType.IsObjectType() && elementType.HasAttribute("key") && elementType.HasAttribute("value") {
+			return tagging.TagsKeyValueList, true
+		}
+	}
+
+	return "", false
+}
+
+// IsReadOnly checks if the attribute is computed by the provider and can't be set.
+func (a *Attribute) IsReadOnly() bool {
+	return a.Computed && !a.Optional && !a.Required
 }
 
 type Block struct {
 	Attributes map[string]*Attribute `json:"attributes"`
+	BlockTypes map[string]*BlockType `json:"block_types"`
 }
 
+// BlockType is the schema of a nested block.
+type BlockType struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       Block  `json:"block"`
+	MinItems    int    `json:"min_items"`
+	MaxItems    int    `json:"max_items"`
+}
+
+// NestedTagAttribute is a tags (or labels) map attribute of a nested block.
+type NestedTagAttribute struct {
+	// Path is the nested block types path (e.g. ["node_config"] or ["spec", "template"]).
+	Path       []string
+	Attribute  string
+	TagsFormat tagging.TagsFormat
+}
+
+func (a NestedTagAttribute) String() string {
+	return strings.Join(append(append([]string{}, a.Path...), a.Attribute), ".")
+}
+
+var nestedTagAttributeNames = []string{"tags", "labels"}
+
 type ResourceSchema struct {
 	Block Block `json:"block"`
 }
@@ -51,9 +116,160 @@ type ProviderSchemas struct {
 	ProviderSchemas map[string]*ProviderSchema `json:"provider_schemas"`
 }
 
-// InitProviderSchemas fetches and stores the provider schemas for a directory
-// This can be called ahead of time to pre-populate the schemas cache
-func InitProviderSchemas(dir string, iacType common.IACType, defaultToTerraform bool) error {
+// loadProviderSchemas fetches the provider schemas of a directory.
+// If cacheDir is set, the schemas are cached on disk by the providers of the dependency lock file
+// (not supported in terragrunt run-all mode, where each module has its own lock file).
+func loadProviderSchemas(dir string, iacType common.IACType, defaultToTerraform bool, cacheDir string) (*ProviderSchemas, error) {
+	cachePath := ""
+
+	if cacheDir != "" && iacType != common.TerragruntRunAll {
+		key, err := getCacheKey(dir)
+		if err != nil {
+			log.Printf("[WARN] Not using the provider schemas cache: %v", err)
+		} else if key == "" {
+			log.Printf("[DEBUG] Not using the provider schemas cache: no providers found in %s", terraform.LockFilename)
+		} else {
+			cachePath = filepath.Join(cacheDir, key+".json")
+		}
+	}
+
+	if cachePath != "" {
+		if providerSchemas := readCache(cachePath); providerSchemas != nil {
+			log.Printf("[INFO] Provider schemas cache hit for directory: %s (%s) with %d providers",
+				dir, cachePath, len(providerSchemas.ProviderSchemas))
+
+			return providerSchemas, nil
+		}
+
+		log.Printf("[INFO] Provider schemas cache miss for directory: %s", dir)
+	}
+
+	documents, err := fetchProviderSchemas(dir, iacType, defaultToTerraform)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new provider schemas object
+	mergedProviderSchemas := &ProviderSchemas{
+		ProviderSchemas: make(map[string]*ProviderSchema),
+	}
+
+	for i, document := range documents {
+		providerSchemas := &ProviderSchemas{}
+		if err := json.Unmarshal(document, providerSchemas); err != nil {
+			if e, ok := err.(*json.SyntaxError); ok {
+				log.Printf("syntax error at byte offset %d", e.Offset)
+			}
+
+			if iacType == common.TerragruntRunAll {
+				log.Printf("[WARN] Failed to unmarshal schema object %d: %v", i+1, err)
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to unmarshal returned provider schemas: %w", err)
+		}
+
+		// Merge this schema into our accumulated schemas
+		mergeProviderSchemas(mergedProviderSchemas, providerSchemas)
+	}
+
+	log.Printf("[INFO] Successfully initialized provider schemas for directory: %s with %d providers",
+		dir, len(mergedProviderSchemas.ProviderSchemas))
+
+	if cachePath != "" {
+		if err := writeCache(cachePath, documents); err != nil {
+			log.Printf("[WARN] Failed to write the provider schemas cache: %v", err)
+		}
+	}
+
+	return mergedProviderSchemas, nil
+}
+
+// ExportProviderSchemas writes the provider schemas of a directory (as returned by 'providers schema -json').
+// The output can be loaded with a Store (see StoreOptions.SchemaFile).
+func ExportProviderSchemas(w io.Writer, dir string, iacType common.IACType, defaultToTerraform bool) error {
+	documents, err := fetchProviderSchemas(dir, iacType, defaultToTerraform)
+	if err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		if _, err := w.Write(append(document, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readProviderSchemasFile reads the provider schemas from a file (or a directory of *.json files)
+// of pre-exported 'providers schema -json' documents, instead of running the IaC binary.
+func readProviderSchemasFile(path string) (*ProviderSchemas, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	paths := []string{path}
+
+	if info.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no schema files (*.json) found in %s", path)
+		}
+
+		sort.Strings(paths)
+	}
+
+	mergedProviderSchemas := &ProviderSchemas{
+		ProviderSchemas: make(map[string]*ProviderSchema),
+	}
+
+	for _, path := range paths {
+		if err := decodeProviderSchemasFile(path, mergedProviderSchemas); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("[INFO] Successfully loaded provider schemas from %s with %d providers",
+		path, len(mergedProviderSchemas.ProviderSchemas))
+
+	return mergedProviderSchemas, nil
+}
+
+// decodeProviderSchemasFile decodes all the (concatenated) schema documents of a file and merges them into target.
+func decodeProviderSchemasFile(path string, target *ProviderSchemas) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+
+	for {
+		providerSchemas := &ProviderSchemas{}
+
+		if err := decoder.Decode(providerSchemas); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to unmarshal provider schemas of %s: %w", path, err)
+		}
+
+		if providerSchemas.ProviderSchemas == nil {
+			return fmt.Errorf("invalid schema file %s: missing provider_schemas", path)
+		}
+
+		mergeProviderSchemas(target, providerSchemas)
+	}
+}
+
+// fetchProviderSchemas runs 'providers schema -json' in a directory, and returns the JSON documents of its output
+// (one per module in terragrunt run-all mode).
+func fetchProviderSchemas(dir string, iacType common.IACType, defaultToTerraform bool) ([][]byte, error) {
 	// Use tofu by default (if it exists).
 	name := "terraform"
 	// For terragrunt - use terragrunt.
@@ -87,62 +303,38 @@ func InitProviderSchemas(dir string, iacType common.IACType, defaultToTerraform
 		log.Printf("Standard output: %s\n", string(out))
 		log.Println("===============================================")
 
-		return fmt.Errorf("failed to execute '%s providers schema -json' command in directory '%s': %w", name, dir, err)
+		return nil, fmt.Errorf("failed to execute '%s providers schema -json' command in directory '%s': %w", name, dir, err)
 	}
 
-	// Create a new provider schemas object
-	mergedProviderSchemas := &ProviderSchemas{
-		ProviderSchemas: make(map[string]*ProviderSchema),
-	}
+	var documents [][]byte
 
-	if iacType == common.TerragruntRunAll {
-		// In run-all mode, we need to parse multiple JSON objects from the output
-		lines := bytes.Split(out, []byte("\n"))
-		jsonCount := 0
-
-		for i, line := range lines {
-			// Skip empty lines and non-JSON lines
-			if len(line) == 0 || line[0] != '{' {
-				continue
-			}
-
-			jsonCount++
-			log.Printf("[INFO] Processing JSON schema object %d from line %d", jsonCount, i+1)
-
-			providerSchemas := &ProviderSchemas{}
-			if err := json.Unmarshal(line, providerSchemas); err != nil {
-				log.Printf("[WARN] Failed to unmarshal schema from line %d: %v", i+1, err)
-				continue
-			}
-
-			// Merge this schema into our accumulated schemas
-			mergeProviderSchemas(mergedProviderSchemas, providerSchemas)
+	// Output can vary between operating systems. Get the JSON output lines.
+	// In run-all mode, there's a JSON object per module.
+	for i, line := range bytes.Split(out, []byte("\n")) {
+		// Skip empty lines and non-JSON lines
+		if len(line) == 0 || line[0] != '{' {
+			continue
 		}
 
-		log.Printf("[INFO] Successfully processed %d valid JSON schema objects", jsonCount)
-	} else {
-		// Standard mode - just parse the single JSON object
-		// Output can vary between operating systems. Get the correct output line.
-		for _, line := range bytes.Split(out, []byte("\n")) {
-			if len(line) > 0 && line[0] == '{' {
-				out = line
-				break
-			}
+		if iacType == common.TerragruntRunAll {
+			log.Printf("[INFO] Processing JSON schema object %d from line %d", len(documents)+1, i+1)
 		}
 
-		if err := json.Unmarshal(out, mergedProviderSchemas); err != nil {
-			if e, ok := err.(*json.SyntaxError); ok {
-				log.Printf("syntax error at byte offset %d", e.Offset)
-			}
-			return fmt.Errorf("failed to unmarshal returned provider schemas: %w", err)
+		documents = append(documents, line)
+
+		if iacType != common.TerragruntRunAll {
+			break
 		}
 	}
 
-	providerSchemasMap[dir] = mergedProviderSchemas
-	log.Printf("[INFO] Successfully initialized provider schemas for directory: %s with %d providers",
-		dir, len(mergedProviderSchemas.ProviderSchemas))
+	if iacType == common.TerragruntRunAll {
+		log.Printf("[INFO] Successfully processed %d valid JSON schema objects", len(documents))
+	} else if len(documents) == 0 {
+		// Fails to unmarshal (as before).
+		documents = append(documents, out)
+	}
 
-	return nil
+	return documents, nil
 }
 
 // mergeProviderSchemas merges the source provider schemas into the target
@@ -163,26 +355,37 @@ func mergeProviderSchemas(target, source *ProviderSchemas) {
 	}
 }
 
-// IsTaggable checks if a resource can be tagged
-func IsTaggable(dir string, resource hclwrite.Block) (bool, error) {
+// IsTaggable checks if a resource (of a root directory) can be tagged (regardless of the Azure type of azapi resources, see providers.GetAzapiType)
+// given the user-defined providers. Returns ErrResourceTypeNotFound if the resource type isn't in the provider schemas
+func (s *Store) IsTaggable(dir string, resource hclwrite.Block, customResourcePrefixes providers.CustomResourcePrefixes) (bool, error) {
 	var isTaggable bool
 
 	resourceType := terraform.GetResourceType(resource)
 
-	if providers.IsSupportedResource(resourceType, resource) {
-		resourceSchema, err := getResourceSchema(resourceType, resource, dir)
+	if customResourcePrefixes.IsSupportedResource(resourceType) {
+		providerSchemas, err := s.Get(dir)
 		if err != nil {
-			if errors.Is(err, ErrResourceTypeNotFound) {
-				log.Print("[WARN] Skipped ", resourceType, " as it is not YET supported")
-
-				return false, nil
-			}
+			return false, err
+		}
 
+		resourceSchema, err := getResourceSchema(resourceType, resource, providerSchemas)
+		if err != nil {
 			return false, err
 		}
 
-		for attribute := range resourceSchema.Block.Attributes {
-			if providers.IsTaggableByAttribute(resourceType, attribute) {
+		tagId := customResourcePrefixes.GetTagIdByResource(resourceType)
+		attribute := getAttribute(resourceSchema.Block, strings.Split(tagId, "."))
+
+		// Nested blocks attributes may be missing from older provider versions (e.g. the node_config.resource_labels
+		// of google_container_node_pool), even for the resources with a tagging function.
+		if attribute == nil && strings.Contains(tagId, ".") {
+			return false, nil
+		}
+
+		// Read-only attributes (e.g. the terraform_labels and effective_labels of google provider 5.x) can't be written.
+		if attribute != nil && !attribute.IsReadOnly() {
+			// The shape of the user-defined providers tags attribute may be configured.
+			if _, ok := attribute.GetTagsFormat(); ok || customResourcePrefixes.GetTagsFormatByResource(resourceType) != "" {
 				isTaggable = true
 			}
 		}
@@ -195,6 +398,99 @@ func IsTaggable(dir string, resource hclwrite.Block) (bool, error) {
 	return isTaggable, nil
 }
 
+// GetTagsFormat returns the shape of a resource tags attribute, or of a nested block attribute given its path
+// (e.g. metadata.labels). A map if the attribute is not in the schema.
+func (s *Store) GetTagsFormat(dir string, resource hclwrite.Block, attributeName string) (tagging.TagsFormat, error) {
+	providerSchemas, err := s.Get(dir)
+	if err != nil {
+		return "", err
+	}
+
+	resourceSchema, err := getResourceSchema(terraform.GetResourceType(resource), resource, providerSchemas)
+	if err != nil {
+		return "", err
+	}
+
+	attribute := getAttribute(resourceSchema.Block, strings.Split(attributeName, "."))
+	if attribute == nil {
+		return tagging.TagsMap, nil
+	}
+
+	tagsFormat, ok := attribute.GetTagsFormat()
+	if !ok {
+		// Resources with a tagging function handle their own tags shape (e.g. the aws_autoscaling_group
+		// tags list of maps of aws < 5).
+		if tagging.HasResourceTagFn(terraform.GetResourceType(resource)) {
+			return tagging.TagsMap, nil
+		}
+
+		return "", fmt.Errorf("unsupported type of %s attribute %s", terraform.GetResourceType(resource), attributeName)
+	}
+
+	return tagsFormat, nil
+}
+
+// GetNestedTagAttributes returns the tags/labels map attributes of the resource nested blocks (recursively), sorted by path.
+func (s *Store) GetNestedTagAttributes(dir string, resource hclwrite.Block) ([]NestedTagAttribute, error) {
+	resourceType := terraform.GetResourceType(resource)
+
+	providerSchemas, err := s.Get(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceSchema, err := getResourceSchema(resourceType, resource, providerSchemas)
+	if err != nil {
+		return nil, err
+	}
+
+	var nestedTagAttributes []NestedTagAttribute
+
+	collectNestedTagAttributes(resourceSchema.Block, nil, &nestedTagAttributes)
+
+	sort.Slice(nestedTagAttributes, func(i, j int) bool {
+		return nestedTagAttributes[i].String() < nestedTagAttributes[j].String()
+	})
+
+	return nestedTagAttributes, nil
+}
+
+// getAttribute returns the attribute at the end of a path of nested blocks (nil if not found).
+func getAttribute(block Block, path []string) *Attribute {
+	for _, name := range path[:len(path)-1] {
+		blockType, ok := block.BlockTypes[name]
+		if !ok {
+			return nil
+		}
+
+		block = blockType.Block
+	}
+
+	return block.Attributes[path[len(path)-1]]
+}
+
+func collectNestedTagAttributes(block Block, path []string, nestedTagAttributes *[]NestedTagAttribute) {
+	for name, blockType := range block.BlockTypes {
+		nestedPath := append(append([]string{}, path...), name)
+
+		for _, attributeName := range nestedTagAttributeNames {
+			attribute, ok := blockType.Block.Attributes[attributeName]
+			if !ok || attribute.IsReadOnly() || (attribute.NestedType == nil && !attribute.Type.IsCollectionType()) {
+				continue
+			}
+
+			tagsFormat, ok := attribute.GetTagsFormat()
+			if !ok {
+				continue
+			}
+
+			*nestedTagAttributes = append(*nestedTagAttributes, NestedTagAttribute{Path: nestedPath, Attribute: attributeName, TagsFormat: tagsFormat})
+		}
+
+		collectNestedTagAttributes(blockType.Block, nestedPath, nestedTagAttributes)
+	}
+}
+
 type TfSchemaAttribute struct {
 	Name string
 	Type string
@@ -224,14 +520,12 @@ func detectProviderName(resource hclwrite.Block) (string, error) {
 	return extractProviderNameFromResourceType(terraform.GetResourceType(resource))
 }
 
-func getResourceSchema(resourceType string, resource hclwrite.Block, dir string) (*ResourceSchema, error) {
+func getResourceSchema(resourceType string, resource hclwrite.Block, providerSchemas *ProviderSchemas) (*ResourceSchema, error) {
 	detectedProviderName, err := detectProviderName(resource)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect provider name for resource %s: %w", resourceType, err)
 	}
 
-	providerSchemas := providerSchemasMap[dir]
-
 	// Search through all providers.
 	for providerName, providerSchema := range providerSchemas.ProviderSchemas {
 		if len(detectedProviderName) > 0 && providerName != detectedProviderName && !strings.HasSuffix(providerName, "/"+detectedProviderName) {
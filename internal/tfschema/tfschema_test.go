@@ -0,0 +1,268 @@
+package tfschema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/env0/terratag/internal/tagging"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProviderSchemasFile(t *testing.T) {
+	dir := t.TempDir()
+
+	// Pretty printed and concatenated (run-all) documents.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/aws": {"resource_schemas": {"aws_s3_bucket": {"block": {"attributes": {"tags": {"type": ["map", "string"], "optional": true}}}}}}
+  }
+}
+{"format_version":"1.0","provider_schemas":{"registry.terraform.io/hashicorp/aws":{"resource_schemas":{"aws_instance":{"block":{"attributes":{}}}}}}}
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"format_version":"1.0","provider_schemas":{"registry.terraform.io/hashicorp/google":{"resource_schemas":{}}}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("not a schema"), 0600))
+
+	providerSchemas, err := NewStore(StoreOptions{SchemaFile: dir}).Get("root")
+	require.NoError(t, err)
+	assert.Len(t, providerSchemas.ProviderSchemas, 2)
+	assert.Contains(t, providerSchemas.ProviderSchemas["registry.terraform.io/hashicorp/aws"].ResourceSchemas, "aws_s3_bucket")
+	assert.Contains(t, providerSchemas.ProviderSchemas["registry.terraform.io/hashicorp/aws"].ResourceSchemas, "aws_instance")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"format_version":"1.0"}`), 0600))
+	_, err = readProviderSchemasFile(dir)
+	assert.ErrorContains(t, err, "missing provider_schemas")
+
+	_, err = NewStore(StoreOptions{SchemaFile: filepath.Join(dir, "missing.json")}).Get("root")
+	assert.ErrorIs(t, err, ErrNoProviderSchemas)
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := getCacheKey(dir)
+	require.NoError(t, err)
+	assert.Empty(t, key, "no lock file")
+
+	writeLockFile := func(version string, hashes string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform.lock.hcl"), []byte(`
+provider "registry.terraform.io/hashicorp/aws" {
+  version = "`+version+`"
+  hashes  = [`+hashes+`]
+}
+`), 0600))
+	}
+
+	writeLockFile("5.0.0", `"h1:a", "zh:b"`)
+	key, err = getCacheKey(dir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, key)
+
+	writeLockFile("5.0.0", `"zh:b", "h1:a"`)
+	sameKey, err := getCacheKey(dir)
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey, "hashes order doesn't matter")
+
+	writeLockFile("5.1.0", `"h1:a", "zh:b"`)
+	otherKey, err := getCacheKey(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+
+	path := filepath.Join(dir, "cache", key+".json")
+	assert.Nil(t, readCache(path))
+
+	require.NoError(t, writeCache(path, [][]byte{
+		[]byte(`{"provider_schemas":{"registry.terraform.io/hashicorp/aws":{"resource_schemas":{"aws_instance":{"block":{"attributes":{}}}}}}}`),
+	}))
+
+	providerSchemas := readCache(path)
+	require.NotNil(t, providerSchemas)
+	assert.Contains(t, providerSchemas.ProviderSchemas["registry.terraform.io/hashicorp/aws"].ResourceSchemas, "aws_instance")
+}
+
+func TestStore(t *testing.T) {
+	var loads atomic.Int32
+
+	store := &Store{entries: map[string]*storeEntry{}}
+	store.load = func(dir string) (*ProviderSchemas, error) {
+		loads.Add(1)
+
+		if dir == "broken" {
+			return nil, errors.New("failed to execute")
+		}
+
+		return &ProviderSchemas{ProviderSchemas: map[string]*ProviderSchema{dir: {}}}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		for _, dir := range []string{"a", "b", "broken"} {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				providerSchemas, err := store.Get(dir)
+				if dir == "broken" {
+					assert.ErrorIs(t, err, ErrNoProviderSchemas)
+					assert.ErrorContains(t, err, "failed to execute")
+				} else {
+					assert.NoError(t, err)
+					assert.Contains(t, providerSchemas.ProviderSchemas, dir)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(3), loads.Load(), "loaded once per directory")
+}
+
+func TestCollectNestedTagAttributes(t *testing.T) {
+	var resourceSchema ResourceSchema
+
+	require.NoError(t, json.Unmarshal([]byte(`{"block": {
+  "attributes": {"labels": {"type": ["map", "string"], "optional": true}},
+  "block_types": {
+    "node_config": {"nesting_mode": "list", "block": {
+      "attributes": {
+        "labels": {"type": ["map", "string"], "optional": true},
+        "resource_labels": {"type": ["map", "string"], "optional": true},
+        "tags": {"type": ["list", "string"], "optional": true},
+        "effective_labels": {"type": ["map", "string"], "computed": true}
+      },
+      "block_types": {
+        "disk": {"nesting_mode": "single", "block": {"attributes": {"labels": {"type": ["map", "string"], "optional": true}}}}
+      }
+    }},
+    "timeouts": {"nesting_mode": "single", "block": {"attributes": {"create": {"type": "string", "optional": true}}}}
+  }
+}}`), &resourceSchema))
+
+	var nestedTagAttributes []NestedTagAttribute
+
+	collectNestedTagAttributes(resourceSchema.Block, nil, &nestedTagAttributes)
+
+	paths := []string{}
+	for _, nestedTagAttribute := range nestedTagAttributes {
+		paths = append(paths, nestedTagAttribute.String())
+	}
+
+	assert.ElementsMatch(t, []string{"node_config.labels", "node_config.disk.labels"}, paths)
+}
+
+func TestAttributeGetTagsFormat(t *testing.T) {
+	testCases := []struct {
+		attribute string
+		expected  tagging.TagsFormat
+		ok        bool
+	}{
+		{attribute: `{"type": ["map", "string"], "optional": true}`, expected: tagging.TagsMap, ok: true},
+		{attribute: `{"type": "dynamic", "optional": true}`, expected: tagging.TagsMap, ok: true},
+		{attribute: `{"type": ["list", ["object", {"key": "string", "value": "string"}]], "optional": true}`, expected: tagging.TagsKeyValueList, ok: true},
+		{attribute: `{"type": ["set", ["object", {"key": "string", "value": "string"}]], "optional": true}`, expected: tagging.TagsKeyValueList, ok: true},
+		{attribute: `{"nested_type": {"attributes": {"key": {"type": "string"}, "value": {"type": "string"}}, "nesting_mode": "set"}, "optional": true}`, expected: tagging.TagsKeyValueList, ok: true},
+		{attribute: `{"nested_type": {"attributes": {"key": {"type": "string"}, "value": {"type": "string"}}, "nesting_mode": "single"}, "optional": true}`},
+		{attribute: `{"type": ["list", "string"], "optional": true}`},
+	}
+
+	for _, tc := range testCases {
+		var attribute Attribute
+
+		require.NoError(t, json.Unmarshal([]byte(tc.attribute), &attribute))
+
+		tagsFormat, ok := attribute.GetTagsFormat()
+		assert.Equal(t, tc.ok, ok, tc.attribute)
+		assert.Equal(t, tc.expected, tagsFormat, tc.attribute)
+	}
+}
+
+func TestStoreGetTagsFormat(t *testing.T) {
+	var providerSchemas ProviderSchemas
+
+	require.NoError(t, json.Unmarshal([]byte(`{"provider_schemas": {"registry.terraform.io/hashicorp/aws": {"resource_schemas": {
+  "aws_autoscaling_group": {"block": {"attributes": {"tags": {"type": ["list", ["map", "string"]], "optional": true}}}},
+  "aws_s3_bucket": {"block": {"attributes": {"tags": {"type": ["list", ["map", "string"]], "optional": true}}}},
+  "aws_vpc": {"block": {"attributes": {"tags": {"type": ["map", "string"], "optional": true}}}}
+}}}}`), &providerSchemas))
+
+	store := &Store{entries: map[string]*storeEntry{}}
+	store.load = func(dir string) (*ProviderSchemas, error) {
+		return &providerSchemas, nil
+	}
+
+	f, diags := hclwrite.ParseConfig([]byte(`resource "aws_autoscaling_group" "a" {
+}
+resource "aws_s3_bucket" "b" {
+}
+resource "aws_vpc" "c" {
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	blocks := f.Body().Blocks()
+
+	// aws < 5 autoscaling group tags are a list of maps, handled by its tagging function.
+	tagsFormat, err := store.GetTagsFormat("root", *blocks[0], "tags")
+	require.NoError(t, err)
+	assert.Equal(t, tagging.TagsMap, tagsFormat)
+
+	_, err = store.GetTagsFormat("root", *blocks[1], "tags")
+	assert.ErrorContains(t, err, "unsupported type of aws_s3_bucket attribute tags")
+
+	tagsFormat, err = store.GetTagsFormat("root", *blocks[2], "tags")
+	require.NoError(t, err)
+	assert.Equal(t, tagging.TagsMap, tagsFormat)
+}
+
+func TestStoreIsTaggable(t *testing.T) {
+	var providerSchemas ProviderSchemas
+
+	// The node_config of older google provider versions has no resource_labels.
+	require.NoError(t, json.Unmarshal([]byte(`{"provider_schemas": {"registry.terraform.io/hashicorp/google": {"resource_schemas": {
+  "google_container_cluster": {"block": {"attributes": {"resource_labels": {"type": ["map", "string"], "optional": true}}}},
+  "google_container_node_pool": {"block": {"block_types": {"node_config": {"nesting_mode": "list", "block": {"attributes": {
+    "labels": {"type": ["map", "string"], "optional": true}
+  }}}}}},
+  "google_storage_bucket": {"block": {"attributes": {"labels": {"type": ["map", "string"], "optional": true}}}}
+}}}}`), &providerSchemas))
+
+	store := &Store{entries: map[string]*storeEntry{}}
+	store.load = func(dir string) (*ProviderSchemas, error) {
+		return &providerSchemas, nil
+	}
+
+	f, diags := hclwrite.ParseConfig([]byte(`resource "google_container_cluster" "a" {
+}
+resource "google_container_node_pool" "b" {
+}
+resource "google_storage_bucket" "c" {
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	blocks := f.Body().Blocks()
+
+	// Tagged by its tagging function (the resource_labels).
+	isTaggable, err := store.IsTaggable("root", *blocks[0], nil)
+	require.NoError(t, err)
+	assert.True(t, isTaggable)
+
+	isTaggable, err = store.IsTaggable("root", *blocks[1], nil)
+	require.NoError(t, err)
+	assert.False(t, isTaggable)
+
+	isTaggable, err = store.IsTaggable("root", *blocks[2], nil)
+	require.NoError(t, err)
+	assert.True(t, isTaggable)
+}
@@ -2,7 +2,7 @@ package utils
 
 import "sort"
 
-func SortObjectKeys(tagsMap map[string]string) []string {
+func SortObjectKeys[V any](tagsMap map[string]V) []string {
 	keys := []string{}
 
 	for key := range tagsMap {
@@ -0,0 +1,100 @@
+package terratag
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/env0/terratag/cli"
+	"github.com/env0/terratag/internal/convert"
+	"github.com/env0/terratag/internal/file"
+	"github.com/env0/terratag/internal/terraform"
+	"github.com/thoas/go-funk"
+)
+
+// revert undoes previous terratag runs in the directory (and its modules).
+// Files are restored from their backups when available, otherwise the terratag changes are structurally removed.
+func revert(args cli.Args) error {
+	matches, err := terraform.GetFilePaths(args.Dir, args.Type)
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{}
+	for _, path := range matches {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+
+	restoredPaths := map[string]bool{}
+
+	var restoredFiles, strippedFiles int
+
+	for _, dir := range funk.UniqString(dirs) {
+		backups, err := doublestar.Glob(dir + "/*.tf.bak")
+		if err != nil {
+			return err
+		}
+
+		for _, backup := range backups {
+			if err := file.RestoreFromBackup(backup); err != nil {
+				return err
+			}
+
+			path := strings.TrimSuffix(backup, ".bak")
+			restoredPaths[path] = true
+			restoredPaths[file.GetTerratagFilename(path)] = true
+			restoredFiles++
+		}
+	}
+
+	for _, path := range matches {
+		if restoredPaths[path] {
+			continue
+		}
+
+		stripped, err := stripTerratagFile(path)
+		if err != nil {
+			return err
+		}
+
+		if stripped {
+			strippedFiles++
+		}
+	}
+
+	log.Print("[INFO] Summary:")
+	log.Print("[INFO] Restored ", restoredFiles, " file/s from backup")
+	log.Print("[INFO] Removed terratag tags from ", strippedFiles, " file/s without a backup")
+
+	return nil
+}
+
+// stripTerratagFile removes the terratag changes from a file that has no backup.
+// A <basename>.terratag.tf file is written back to <basename>.tf.
+func stripTerratagFile(path string) (bool, error) {
+	hcl, err := file.ReadHCLFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	modified, err := convert.RemoveTerratagTags(hcl)
+	if err != nil || !modified {
+		return false, err
+	}
+
+	log.Print("[INFO] Removing terratag tags from ", path)
+
+	text := strings.TrimRight(string(hcl.Bytes()), "\n") + "\n"
+
+	if !strings.HasSuffix(path, ".terratag.tf") {
+		return true, file.CreateFile(path, text)
+	}
+
+	if err := file.CreateFile(strings.TrimSuffix(path, ".terratag.tf")+".tf", text); err != nil {
+		return false, err
+	}
+
+	return true, os.Remove(path)
+}
@@ -0,0 +1,42 @@
+package terratag
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/env0/terratag/cli"
+	"github.com/env0/terratag/internal/common"
+	"github.com/env0/terratag/internal/terraform"
+	"github.com/env0/terratag/internal/tfschema"
+)
+
+// ExportSchema writes the provider schemas of a directory, to be used later with -schema-file
+// (e.g. in environments without terraform/tofu/terragrunt).
+func ExportSchema(args cli.SchemaExportArgs) error {
+	if err := terraform.ValidateInitRun(args.Dir, args.Type); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if args.Out != "" {
+		f, err := os.Create(args.Out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	if err := tfschema.ExportProviderSchemas(w, args.Dir, common.IACType(args.Type), args.DefaultToTerraform); err != nil {
+		return err
+	}
+
+	if args.Out != "" {
+		log.Print("[INFO] Provider schemas exported to ", args.Out)
+	}
+
+	return nil
+}
@@ -2,8 +2,12 @@ package terratag
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -11,15 +15,18 @@ import (
 
 	"github.com/env0/terratag/cli"
 	"github.com/env0/terratag/internal/common"
+	"github.com/env0/terratag/internal/config"
 	"github.com/env0/terratag/internal/convert"
 	"github.com/env0/terratag/internal/file"
 	"github.com/env0/terratag/internal/providers"
+	"github.com/env0/terratag/internal/report"
 	"github.com/env0/terratag/internal/tag_keys"
 	"github.com/env0/terratag/internal/tagging"
 	"github.com/env0/terratag/internal/terraform"
 	"github.com/env0/terratag/internal/tfschema"
 	"github.com/env0/terratag/internal/utils"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type counters struct {
@@ -27,22 +34,34 @@ type counters struct {
 	taggedResources uint32
 	totalFiles      uint32
 	taggedFiles     uint32
+	failedFiles     uint32
 }
 
-var pairRegex = regexp.MustCompile(`^([a-zA-Z][\w-]*)=([\w-]+)$`)
+// Values (and keys, after the first letter) may contain letters, digits, spaces and _.:/=+-@.
+// Values may also be "expr:" expressions (without commas).
+var pairRegex = regexp.MustCompile(`^([a-zA-Z][\w.:/@+-]*)=([\w .:/=+@-]+|` + common.ExpressionPrefix + `[^,]+)$`)
 
-var matchWaitGroup sync.WaitGroup
+// stdoutLock serializes writes to stdout from the per file goroutines.
+var stdoutLock sync.Mutex
 
 func (c *counters) Add(other counters) {
 	atomic.AddUint32(&c.totalResources, other.totalResources)
 	atomic.AddUint32(&c.taggedResources, other.taggedResources)
 	atomic.AddUint32(&c.totalFiles, other.totalFiles)
 	atomic.AddUint32(&c.taggedFiles, other.taggedFiles)
+	atomic.AddUint32(&c.failedFiles, other.failedFiles)
 }
 
 func Terratag(args cli.Args) error {
-	if err := terraform.ValidateInitRun(args.Dir, args.Type); err != nil {
-		return err
+	if args.Revert {
+		return revert(args)
+	}
+
+	// With a schema file, the IaC binary isn't used (init is only required to find the modules).
+	if args.SchemaFile == "" {
+		if err := terraform.ValidateInitRun(args.Dir, args.Type); err != nil {
+			return err
+		}
 	}
 
 	matches, err := terraform.GetFilePaths(args.Dir, args.Type)
@@ -50,40 +69,149 @@ func Terratag(args cli.Args) error {
 		return err
 	}
 
+	tags, err := loadTags(args)
+	if err != nil {
+		return err
+	}
+
+	var defaultTagsProviders map[string]bool
+
+	if args.Strategy == string(common.ProviderDefaultsStrategy) {
+		lockedProviders, err := terraform.GetLockedProviders(args.Dir)
+		if err != nil {
+			return err
+		}
+
+		defaultTagsProviders = getDefaultTagsProviders(matches, lockedProviders)
+
+		log.Print("[INFO] Tagging the default tags of the provider blocks of: ", strings.Join(utils.SortObjectKeys(defaultTagsProviders), ", "))
+	}
+
+	var tagsConfig *config.Config
+
+	if args.Config != "" {
+		if tagsConfig, err = config.Load(args.Config); err != nil {
+			return err
+		}
+	}
+
 	taggingArgs := &common.TaggingArgs{
-		Filter:              args.Filter,
-		Skip:                args.Skip,
-		Dir:                 args.Dir,
-		Tags:                args.Tags,
-		Matches:             matches,
-		IsSkipTerratagFiles: args.IsSkipTerratagFiles,
+		Filter:  args.Filter,
+		Skip:    args.Skip,
+		Dir:     args.Dir,
+		Tags:    tags,
+		Matches: matches,
+		// In check mode the previously tagged files are the ones being verified.
+		IsSkipTerratagFiles: args.IsSkipTerratagFiles && !args.Check,
 		Rename:              args.Rename,
 		IACType:             common.IACType(args.Type),
 		DefaultToTerraform:  args.DefaultToTerraform,
 		KeepExistingTags:    args.KeepExistingTags,
+		DryRun:              args.DryRun,
+		Check:               args.Check,
+		Config:              tagsConfig,
+		Normalize:           args.Normalize,
+		Strategy:            common.TaggingStrategy(args.Strategy),
+		TagNestedBlocks:     args.TagNestedBlocks,
+		// Resources of providers without provider blocks are tagged individually.
+		DefaultTagsProviders: defaultTagsProviders,
 	}
 
-	// Initialize provider schemas before processing files
-	if err := tfschema.InitProviderSchemas(args.Dir, common.IACType(args.Type), args.DefaultToTerraform); err != nil {
-		log.Printf("[WARN] Failed to pre-initialize provider schemas: %v", err)
-		// Continue even if initialization fails, as getResourceSchema will try again on-demand
+	schemas := tfschema.NewStore(tfschema.StoreOptions{
+		IACType:            common.IACType(args.Type),
+		DefaultToTerraform: args.DefaultToTerraform,
+		CacheDir:           getSchemaCacheDir(args),
+		SchemaFile:         args.SchemaFile,
+	})
+
+	// Initialize provider schemas before processing files (fails early instead of failing every file).
+	if _, err := schemas.Get(args.Dir); err != nil {
+		return err
 	}
 
-	counters := tagDirectoryResources(taggingArgs)
+	runReport := &report.Report{}
+
+	counters := tagDirectoryResources(taggingArgs, schemas, runReport)
 
 	log.Print("[INFO] Summary:")
 	log.Print("[INFO] Tagged ", counters.taggedResources, " resource/s (out of ", counters.totalResources, " resource/s processed)")
 	log.Print("[INFO] In ", counters.taggedFiles, " file/s (out of ", counters.totalFiles, " file/s processed)")
 
+	if args.Report != "" {
+		if err := writeReport(args, runReport, counters); err != nil {
+			return err
+		}
+	}
+
+	if args.Check {
+		return checkUntaggedResources(runReport, counters)
+	}
+
+	return nil
+}
+
+func writeReport(args cli.Args, runReport *report.Report, total counters) error {
+	runReport.Summary = report.Summary{
+		TotalResources:  total.totalResources,
+		TaggedResources: total.taggedResources,
+		TotalFiles:      total.totalFiles,
+		TaggedFiles:     total.taggedFiles,
+	}
+
+	if args.ReportFile == "" {
+		stdoutLock.Lock()
+		defer stdoutLock.Unlock()
+
+		return runReport.WriteJSON(os.Stdout)
+	}
+
+	log.Print("[INFO] Writing report to ", args.ReportFile)
+
+	f, err := os.Create(args.ReportFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return runReport.WriteJSON(f)
+}
+
+func checkUntaggedResources(runReport *report.Report, total counters) error {
+	untagged := runReport.GetResources(report.ResourceUntagged)
+	paths := utils.SortObjectKeys(untagged)
+
+	count := 0
+
+	for _, path := range paths {
+		for _, resource := range untagged[path] {
+			fmt.Printf("%s:%d: %s.%s is not tagged\n", path, resource.Line, resource.Type, resource.Name)
+
+			count++
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf("check failed: %d resource/s are not tagged", count)
+	}
+
+	if total.failedFiles > 0 {
+		return fmt.Errorf("check failed: %d file/s could not be processed", total.failedFiles)
+	}
+
 	return nil
 }
 
-func tagDirectoryResources(args *common.TaggingArgs) counters {
+func tagDirectoryResources(args *common.TaggingArgs, schemas *tfschema.Store, runReport *report.Report) counters {
 	var total counters
 
+	var matchWaitGroup sync.WaitGroup
+
+	references := getStringReferences(args.Matches)
+
 	for _, path := range args.Matches {
 		if args.IsSkipTerratagFiles && strings.HasSuffix(path, "terratag.tf") {
 			log.Print("[INFO] Skipping file ", path, " as it's already tagged")
+			runReport.AddFile(&report.File{Path: path, Status: report.FileSkipped})
 		} else {
 			matchWaitGroup.Add(1)
 
@@ -94,15 +222,26 @@ func tagDirectoryResources(args *common.TaggingArgs) counters {
 					totalFiles: 1,
 				})
 
+				fileReport := &report.File{Path: path, Status: report.FileProcessed}
+				defer runReport.AddFile(fileReport)
+
 				defer func() {
 					if r := recover(); r != nil {
 						log.Printf("[ERROR] failed to process %s due to an exception\n%v", path, r)
+						total.Add(counters{failedFiles: 1})
+
+						fileReport.Status = report.FileError
+						fileReport.Error = fmt.Sprint(r)
 					}
 				}()
 
-				perFile, err := tagFileResources(path, args)
+				perFile, err := tagFileResources(path, args, schemas, references[filepath.Dir(path)], fileReport)
 				if err != nil {
 					log.Printf("[ERROR] failed to process %s due to an error\n%v", path, err)
+					total.Add(counters{failedFiles: 1})
+
+					fileReport.Status = report.FileError
+					fileReport.Error = err.Error()
 
 					return
 				}
@@ -117,7 +256,30 @@ func tagDirectoryResources(args *common.TaggingArgs) counters {
 	return total
 }
 
-func tagFileResources(path string, args *common.TaggingArgs) (*counters, error) {
+// getStringReferences returns the string locals and variables (resolving the type of azapi resources) of the directories
+// of the files, by directory. They are read before any file is tagged (and possibly renamed).
+func getStringReferences(paths []string) map[string]map[string]string {
+	references := map[string]map[string]string{}
+
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if _, ok := references[dir]; ok {
+			continue
+		}
+
+		dirReferences, err := terraform.GetStringReferences(dir)
+		if err != nil {
+			log.Printf("[WARN] failed to read the locals and variables of %s: %v", dir, err)
+		}
+
+		references[dir] = dirReferences
+	}
+
+	return references
+}
+
+// tagFileResources tags the resources of a file. The references are the string locals and variables of its directory (see getStringReferences).
+func tagFileResources(path string, args *common.TaggingArgs, schemas *tfschema.Store, references map[string]string, fileReport *report.File) (*counters, error) {
 	perFileCounters := counters{}
 
 	log.Print("[INFO] Processing file ", path)
@@ -129,25 +291,40 @@ func tagFileResources(path string, args *common.TaggingArgs) (*counters, error)
 		return nil, err
 	}
 
-	filename := file.GetFilename(path)
-
-	hclMap, err := toHclMap(args.Tags)
+	lines, err := file.GetBlockLines(path)
 	if err != nil {
 		return nil, err
 	}
 
+	filename := file.GetFilename(path)
+
+	customResourcePrefixes := getCustomResourcePrefixes(args.Config)
+
 	terratag := common.TerratagLocal{
 		Found: map[string]hclwrite.Tokens{},
-		Added: hclMap,
 	}
 
-	for _, resource := range hcl.Body().Blocks() {
+	// The terratag locals (name to tags map) of the tag sets used in this file.
+	added := map[string]string{}
+	// The terratag locals already in the file (from previous runs).
+	existingLocals := map[string]*hclwrite.Attribute{}
+
+	for i, resource := range hcl.Body().Blocks() {
 		switch resource.Type() {
 		case "resource":
 			log.Print("[INFO] Processing resource ", resource.Labels())
 
 			perFileCounters.totalResources += 1
 
+			resourceType := terraform.GetResourceType(*resource)
+			resourceReport := report.Resource{
+				Type:     resourceType,
+				Name:     resource.Labels()[1],
+				Line:     lines[i],
+				Provider: string(customResourcePrefixes.GetProviderByResource(resourceType)),
+				Status:   report.ResourceSkipped,
+			}
+
 			matched, err := regexp.MatchString(args.Filter, resource.Labels()[0])
 			if err != nil {
 				return nil, err
@@ -156,6 +333,9 @@ func tagFileResources(path string, args *common.TaggingArgs) (*counters, error)
 			if !matched {
 				log.Print("[INFO] Resource excluded by filter, skipping.", resource.Labels())
 
+				resourceReport.SkipReason = report.SkipReasonFilter
+				fileReport.Resources = append(fileReport.Resources, resourceReport)
+
 				continue
 			}
 
@@ -168,71 +348,260 @@ func tagFileResources(path string, args *common.TaggingArgs) (*counters, error)
 				if matched {
 					log.Print("[INFO] Resource excluded by skip, skipping.", resource.Labels())
 
+					resourceReport.SkipReason = report.SkipReasonSkip
+					fileReport.Resources = append(fileReport.Resources, resourceReport)
+
 					continue
 				}
 			}
 
-			isTaggable, err := tfschema.IsTaggable(args.Dir, *resource)
+			if providers.IsAzapiResource(resourceType) {
+				azureType, ok := providers.GetAzapiType(*resource, references)
+				if !ok {
+					log.Print("[WARN] Skipped ", resource.Labels(), " as its Azure resource type can't be determined")
+
+					resourceReport.SkipReason = report.SkipReasonUnknownType
+					fileReport.Resources = append(fileReport.Resources, resourceReport)
+
+					continue
+				}
+
+				if !providers.IsAzureTypeTaggable(azureType) {
+					log.Print("[INFO] Resource not taggable, skipping.", resource.Labels())
+
+					resourceReport.SkipReason = report.SkipReasonNotTaggable
+					fileReport.Resources = append(fileReport.Resources, resourceReport)
+
+					continue
+				}
+
+				if support, _ := providers.GetAzureTagSupport(azureType); !support.TagsInCostReport {
+					log.Print("[WARN] The tags of ", resource.Labels(), " (", azureType, ") don't appear in the Azure cost reports")
+				}
+			}
+
+			isTaggable, err := schemas.IsTaggable(args.Dir, *resource, customResourcePrefixes)
 			if err != nil {
-				return nil, err
+				if !errors.Is(err, tfschema.ErrResourceTypeNotFound) {
+					return nil, err
+				}
+
+				log.Print("[WARN] Skipped ", resourceType, " as it is not YET supported")
+
+				resourceReport.SkipReason = report.SkipReasonSchemaNotFound
+				fileReport.Resources = append(fileReport.Resources, resourceReport)
+
+				continue
 			}
 
-			if isTaggable {
+			if isTaggable && args.Strategy == common.ProviderDefaultsStrategy &&
+				args.DefaultTagsProviders[getResourceProviderBlockName(resource)] && !providers.IsIgnoringDefaultTags(resourceType) {
+				log.Print("[INFO] Resource tagged by the provider default tags, skipping.", resource.Labels())
+
+				resourceReport.SkipReason = report.SkipReasonProviderDefaults
+			} else if isTaggable {
 				log.Print("[INFO] Resource taggable, processing...", resource.Labels())
 
-				perFileCounters.taggedResources += 1
+				tagSet, tags, normalizations, err := getTagSet(args, resourceReport.Provider, resourceType)
+				if err != nil {
+					return nil, err
+				}
 
-				result, err := tagging.TagResource(tagging.TagBlockArgs{
+				tagBlockArgs := tagging.TagBlockArgs{
 					Filename:         filename,
 					Block:            resource,
-					Tags:             args.Tags,
+					Tags:             tags,
 					Terratag:         terratag,
-					TagId:            providers.GetTagIdByResource(terraform.GetResourceType(*resource)),
+					TagId:            customResourcePrefixes.GetTagIdByResource(resourceType),
 					KeepExistingTags: args.KeepExistingTags,
-				})
+					TagSet:           tagSet,
+				}
+
+				if resourceReport.Provider == providers.OCI {
+					tagBlockArgs.TagId, _ = args.Config.GetOCITagAttribute()
+				}
+
+				resourceReport.Status = report.ResourceTagged
+				resourceReport.TagAttribute = tagBlockArgs.TagId
+				resourceReport.TagsLocal = tagBlockArgs.GetTerratagAddedKey()
+				resourceReport.Normalizations = normalizations
+
+				if args.Check {
+					isTagged, err := tagging.IsResourceTagged(tagBlockArgs)
+					if err != nil {
+						return nil, err
+					}
+
+					if !isTagged {
+						resourceReport.Status = report.ResourceUntagged
+					}
+
+					fileReport.Resources = append(fileReport.Resources, resourceReport)
+
+					continue
+				}
+
+				perFileCounters.taggedResources += 1
+
+				if tagsFormat := customResourcePrefixes.GetTagsFormatByResource(resourceType); tagsFormat != "" {
+					tagBlockArgs.TagsFormat = tagging.TagsFormat(tagsFormat)
+				} else if tagBlockArgs.TagsFormat, err = schemas.GetTagsFormat(args.Dir, *resource, tagBlockArgs.TagId); err != nil {
+					return nil, err
+				}
+
+				result, err := tagging.TagResource(tagBlockArgs)
 				if err != nil {
 					return nil, err
 				}
 
+				_, resourceReport.MergedExistingTags = terratag.Found[tag_keys.GetResourceExistingTagsKey(filename, resource)]
+
+				if err := addTerratagLocals(added, resourceReport, tags); err != nil {
+					return nil, err
+				}
+
 				swappedTagsStrings = append(swappedTagsStrings, result.SwappedTagsStrings...)
+
+				if resourceReport.Provider == providers.KUBERNETES && args.Config.IsTaggingPodTemplates() {
+					podTemplatePaths, podTemplateSwappedTagsStrings, err := tagPodTemplates(tagBlockArgs)
+					if err != nil {
+						return nil, err
+					}
+
+					resourceReport.NestedTagPaths = podTemplatePaths
+					swappedTagsStrings = append(swappedTagsStrings, podTemplateSwappedTagsStrings...)
+				}
+
+				if args.TagNestedBlocks {
+					nestedTagPaths, nestedSwappedTagsStrings, err := tagNestedBlocks(args, schemas, tagBlockArgs)
+					if err != nil {
+						return nil, err
+					}
+
+					resourceReport.NestedTagPaths = append(resourceReport.NestedTagPaths, nestedTagPaths...)
+					swappedTagsStrings = append(swappedTagsStrings, nestedSwappedTagsStrings...)
+				}
 			} else {
 				log.Print("[INFO] Resource not taggable, skipping.", resource.Labels())
+
+				resourceReport.SkipReason = report.SkipReasonNotTaggable
+			}
+
+			fileReport.Resources = append(fileReport.Resources, resourceReport)
+		case "provider":
+			if args.Strategy != common.ProviderDefaultsStrategy {
+				continue
+			}
+
+			provider, defaultTags := providers.GetDefaultTagsByProviderBlock(resource.Labels()[0])
+			if defaultTags == nil || !args.DefaultTagsProviders[getProviderBlockName(resource)] {
+				continue
+			}
+
+			providerReport := report.Resource{
+				Type:         "provider",
+				Name:         getProviderBlockName(resource),
+				Line:         lines[i],
+				Provider:     string(provider),
+				Status:       report.ResourceTagged,
+				TagAttribute: strings.TrimPrefix(defaultTags.Block+"."+defaultTags.Attribute, "."),
+			}
+
+			tagSet, tags, normalizations, err := getTagSet(args, providerReport.Provider, "")
+			if err != nil {
+				return nil, err
+			}
+
+			tagBlockArgs := tagging.TagBlockArgs{
+				Filename:         filename,
+				Block:            resource,
+				Tags:             tags,
+				Terratag:         terratag,
+				KeepExistingTags: args.KeepExistingTags,
+				TagSet:           tagSet,
+			}
+
+			providerReport.TagsLocal = tagBlockArgs.GetTerratagAddedKey()
+			providerReport.Normalizations = normalizations
+
+			if args.Check {
+				if !tagging.IsProviderDefaultsTagged(resource, *defaultTags) {
+					providerReport.Status = report.ResourceUntagged
+				}
+
+				fileReport.Resources = append(fileReport.Resources, providerReport)
+
+				continue
 			}
+
+			log.Print("[INFO] Tagging provider default tags ", providerReport.Name)
+
+			result, err := tagging.TagProviderDefaults(tagBlockArgs, *defaultTags)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := addTerratagLocals(added, providerReport, tags); err != nil {
+				return nil, err
+			}
+
+			swappedTagsStrings = append(swappedTagsStrings, result.SwappedTagsStrings...)
+			fileReport.Resources = append(fileReport.Resources, providerReport)
 		case "locals":
 			// Checks if terratag_added_* exists.
 			// If it exists no need to append it again to Terratag file.
 			// Instead should override it.
-			attributes := resource.Body().Attributes()
-			key := tag_keys.GetTerratagAddedKey(filename)
-
-			for attributeKey, attribute := range attributes {
-				if attributeKey == key {
-					mergedAdded, err := convert.MergeTerratagLocals(attribute, terratag.Added)
-					if err != nil {
-						return nil, err
-					}
-
-					terratag.Added = mergedAdded
-
-					break
+			for attributeKey, attribute := range resource.Body().Attributes() {
+				if tag_keys.IsTerratagAddedKey(attributeKey) {
+					existingLocals[attributeKey] = attribute
 				}
 			}
 		}
 	}
 
+	if args.Check {
+		return &perFileCounters, nil
+	}
+
 	if len(swappedTagsStrings) > 0 {
-		convert.AppendLocalsBlock(hcl, filename, terratag)
+		for key, attribute := range existingLocals {
+			if _, ok := added[key]; !ok {
+				continue
+			}
+
+			mergedAdded, err := convert.MergeTerratagLocals(attribute, added[key])
+			if err != nil {
+				return nil, err
+			}
+
+			added[key] = mergedAdded
+		}
+
+		convert.AppendLocalsBlock(hcl, added)
 
 		text := string(hcl.Bytes())
 
-		swappedTagsStrings = append(swappedTagsStrings, terratag.Added)
+		for _, key := range utils.SortObjectKeys(added) {
+			swappedTagsStrings = append(swappedTagsStrings, added[key])
+		}
+
 		text = convert.UnquoteTagsAttribute(swappedTagsStrings, text)
 
-		if err := file.ReplaceWithTerratagFile(path, text, args.Rename); err != nil {
+		if args.DryRun {
+			diff, err := file.GetTerratagFileDiff(path, text, args.Rename)
+			if err != nil {
+				return nil, err
+			}
+
+			stdoutLock.Lock()
+			fmt.Print(diff)
+			stdoutLock.Unlock()
+		} else if err := file.ReplaceWithTerratagFile(path, text, args.Rename); err != nil {
 			return nil, err
 		}
 
 		perFileCounters.taggedFiles = 1
+		fileReport.Status = report.FileTagged
 	} else {
 		log.Print("[INFO] No taggable resources found in file ", path, " - skipping")
 	}
@@ -240,7 +609,262 @@ func tagFileResources(path string, args *common.TaggingArgs) (*counters, error)
 	return &perFileCounters, nil
 }
 
-func toHclMap(tags string) (string, error) {
+// tagNestedBlocks tags the existing nested blocks of a resource that have a tags/labels map attribute in the provider schema.
+// Returns the tagged nested paths (e.g. root_block_device.tags).
+func tagNestedBlocks(args *common.TaggingArgs, schemas *tfschema.Store, tagBlockArgs tagging.TagBlockArgs) ([]string, []string, error) {
+	nestedTagAttributes, err := schemas.GetNestedTagAttributes(args.Dir, *tagBlockArgs.Block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nestedTagPaths, swappedTagsStrings []string
+
+	for _, nestedTagAttribute := range nestedTagAttributes {
+		if tagging.IsConflictingNestedTagAttribute(tagBlockArgs.Block, nestedTagAttribute.String()) {
+			log.Print("[INFO] Skipped nested ", nestedTagAttribute, " of ", tagBlockArgs.Block.Labels(), " as it conflicts with the tagged attributes")
+
+			continue
+		}
+
+		result, err := tagging.TagNestedBlocks(tagBlockArgs, nestedTagAttribute.Path, nestedTagAttribute.Attribute, nestedTagAttribute.TagsFormat)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(result.SwappedTagsStrings) == 0 {
+			continue
+		}
+
+		log.Print("[INFO] Tagged nested ", nestedTagAttribute, " of ", tagBlockArgs.Block.Labels())
+
+		nestedTagPaths = append(nestedTagPaths, nestedTagAttribute.String())
+		swappedTagsStrings = append(swappedTagsStrings, result.SwappedTagsStrings...)
+	}
+
+	return nestedTagPaths, swappedTagsStrings, nil
+}
+
+// tagPodTemplates tags the pod templates labels of a kubernetes workload. Returns the tagged paths.
+func tagPodTemplates(tagBlockArgs tagging.TagBlockArgs) ([]string, []string, error) {
+	var podTemplatePaths, swappedTagsStrings []string
+
+	for _, path := range tagging.PodTemplatePaths {
+		result, err := tagging.TagPodTemplates(tagBlockArgs, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(result.SwappedTagsStrings) == 0 {
+			continue
+		}
+
+		podTemplatePath := strings.Join(path, ".") + ".metadata.labels"
+
+		log.Print("[INFO] Tagged pod template ", podTemplatePath, " of ", tagBlockArgs.Block.Labels())
+
+		podTemplatePaths = append(podTemplatePaths, podTemplatePath)
+		swappedTagsStrings = append(swappedTagsStrings, result.SwappedTagsStrings...)
+	}
+
+	return podTemplatePaths, swappedTagsStrings, nil
+}
+
+// addTerratagLocals adds the tags of the resource tag set to the file terratag locals (if not added already).
+func addTerratagLocals(added map[string]string, resourceReport report.Resource, tags string) error {
+	if _, ok := added[resourceReport.TagsLocal]; ok {
+		return nil
+	}
+
+	hclMap, err := toHclMap(tags)
+	if err != nil {
+		return err
+	}
+
+	added[resourceReport.TagsLocal] = hclMap
+
+	for _, normalization := range resourceReport.Normalizations {
+		log.Print("[WARN] Normalized ", resourceReport.Provider, " tag ", normalization, " in ", resourceReport.TagsLocal)
+	}
+
+	return nil
+}
+
+// getProviderBlockName returns the provider name, with its alias (if any) e.g. aws.west
+func getProviderBlockName(block *hclwrite.Block) string {
+	name := block.Labels()[0]
+
+	if alias := block.Body().GetAttribute("alias"); alias != nil {
+		name += "." + strings.Trim(strings.TrimSpace(string(alias.Expr().BuildTokens(hclwrite.Tokens{}).Bytes())), "\"")
+	}
+
+	return name
+}
+
+// getSchemaCacheDir returns the provider schemas cache directory (an empty string if caching is disabled).
+func getSchemaCacheDir(args cli.Args) string {
+	if args.NoSchemaCache {
+		return ""
+	}
+
+	if args.SchemaCacheDir != "" {
+		return args.SchemaCacheDir
+	}
+
+	return tfschema.DefaultCacheDir()
+}
+
+// getResourceProviderBlockName returns the name of the provider block of a resource, with its alias (if any) e.g. google-beta or aws.west
+// (see getProviderBlockName). Resources without a provider attribute use the default (unaliased) provider block.
+func getResourceProviderBlockName(resource *hclwrite.Block) string {
+	if provider := resource.Body().GetAttribute("provider"); provider != nil {
+		name := strings.TrimSpace(string(provider.Expr().BuildTokens(hclwrite.Tokens{}).Bytes()))
+
+		return strings.Trim(name, "\"")
+	}
+
+	return strings.SplitN(resource.Labels()[0], "_", 2)[0]
+}
+
+// getDefaultTagsProviders returns the names of the provider blocks (with default tags support) found in any of the files, with their alias e.g. aws.west.
+// Providers locked to a version that doesn't support default tags are excluded (their resources are tagged individually).
+func getDefaultTagsProviders(paths []string, lockedProviders map[string]*terraform.LockedProvider) map[string]bool {
+	defaultTagsProviders := map[string]bool{}
+	unsupportedProviders := map[string]bool{}
+
+	for _, path := range paths {
+		hcl, err := file.ReadHCLFile(path)
+		if err != nil {
+			// Invalid files are reported when processed.
+			continue
+		}
+
+		for _, block := range hcl.Body().Blocks() {
+			if block.Type() != "provider" || len(block.Labels()) == 0 {
+				continue
+			}
+
+			name := block.Labels()[0]
+
+			_, defaultTags := providers.GetDefaultTagsByProviderBlock(name)
+			if defaultTags == nil || unsupportedProviders[name] {
+				continue
+			}
+
+			if defaultTags.MinMajorVersion > 0 {
+				version := terraform.GetLockedProviderVersion(lockedProviders, name)
+
+				if version == "" {
+					log.Printf("[WARN] Provider %s version not found in %s, tagging its resources instead of %s", name, terraform.LockFilename, defaultTags.Attribute)
+				} else if !defaultTags.IsSupportedVersion(version) {
+					log.Printf("[WARN] Provider %s version %s does not support %s (requires version %d or later), tagging its resources instead", name, version, defaultTags.Attribute, defaultTags.MinMajorVersion)
+				}
+
+				if !defaultTags.IsSupportedVersion(version) {
+					unsupportedProviders[name] = true
+
+					continue
+				}
+			}
+
+			defaultTagsProviders[getProviderBlockName(block)] = true
+		}
+	}
+
+	return defaultTagsProviders
+}
+
+// getCustomResourcePrefixes returns the user-defined providers of the config file.
+func getCustomResourcePrefixes(tagsConfig *config.Config) providers.CustomResourcePrefixes {
+	if tagsConfig == nil {
+		return nil
+	}
+
+	resourcePrefixes := make(providers.CustomResourcePrefixes, 0, len(tagsConfig.Providers))
+
+	for _, provider := range tagsConfig.Providers {
+		resourcePrefixes = append(resourcePrefixes, providers.ResourcePrefix{
+			Prefix:       provider.Prefix,
+			Provider:     providers.Provider(provider.Name),
+			TagAttribute: provider.TagAttribute,
+			TagsFormat:   provider.Format,
+		})
+	}
+
+	return resourcePrefixes
+}
+
+// getTagSet returns the name of the resource tag set (see config.GetTagSet) and its tags as a JSON document.
+// The tags are validated (and normalized) according to the provider rules.
+func getTagSet(args *common.TaggingArgs, provider string, resourceType string) (string, string, []providers.TagNormalization, error) {
+	tagSet, setTags := args.Config.GetTagSet(provider, resourceType)
+
+	tagsMap, err := parseTags(args.Tags)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	maps.Copy(tagsMap, setTags)
+
+	tagsMap, normalizations, err := providers.ValidateTags(providers.Provider(provider), tagsMap, args.Normalize)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%s: %w", resourceType, err)
+	}
+
+	isProviderSpecific := len(normalizations) > 0
+
+	if provider == providers.OCI {
+		if _, namespace := args.Config.GetOCITagAttribute(); namespace != "" {
+			tagsMap = providers.QualifyDefinedTags(namespace, tagsMap)
+			isProviderSpecific = true
+		}
+	}
+
+	// The normalized (and the OCI defined) tags are specific to the provider, and may not share the default tags locals.
+	// The other tag sets are named after their provider already (see config.GetTagSet).
+	if isProviderSpecific && tagSet == "" {
+		tagSet = provider
+	}
+
+	tags, err := json.Marshal(tagsMap)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return tagSet, string(tags), normalizations, nil
+}
+
+// loadTags merges the tags from the tags file (if any) with the -tags input (which takes precedence).
+// The tags are returned as a JSON document.
+func loadTags(args cli.Args) (string, error) {
+	tagsMap := map[string]string{}
+
+	if args.TagsFile != "" {
+		fileTags, err := file.ReadTagsFile(args.TagsFile)
+		if err != nil {
+			return "", err
+		}
+
+		maps.Copy(tagsMap, fileTags)
+	}
+
+	if args.Tags != "" {
+		inputTags, err := parseTags(args.Tags)
+		if err != nil {
+			return "", err
+		}
+
+		maps.Copy(tagsMap, inputTags)
+	}
+
+	tags, err := json.Marshal(tagsMap)
+	if err != nil {
+		return "", err
+	}
+
+	return string(tags), nil
+}
+
+func parseTags(tags string) (map[string]string, error) {
 	var tagsMap map[string]string
 
 	if err := json.Unmarshal([]byte(tags), &tagsMap); err != nil {
@@ -251,19 +875,33 @@ func toHclMap(tags string) (string, error) {
 		for _, pair := range pairs {
 			match := pairRegex.FindStringSubmatch(pair)
 			if match == nil {
-				return "", fmt.Errorf("invalid input tags! must be a valid JSON or pairs of key=value.\nInput: %s", tags)
+				return nil, fmt.Errorf("invalid input tags! must be a valid JSON or pairs of key=value.\nInput: %s", tags)
 			}
 
 			tagsMap[match[1]] = match[2]
 		}
 	}
 
+	return tagsMap, nil
+}
+
+func toHclMap(tags string) (string, error) {
+	tagsMap, err := parseTags(tags)
+	if err != nil {
+		return "", err
+	}
+
 	keys := utils.SortObjectKeys(tagsMap)
 
 	mapContent := []string{}
 
 	for _, key := range keys {
-		mapContent = append(mapContent, "\""+key+"\"="+"\""+tagsMap[key]+"\"")
+		value, err := convert.GetTagValueExpression(tagsMap[key])
+		if err != nil {
+			return "", err
+		}
+
+		mapContent = append(mapContent, string(hclwrite.TokensForValue(cty.StringVal(key)).Bytes())+"="+value)
 	}
 
 	return "{" + strings.Join(mapContent, ",") + "}", nil
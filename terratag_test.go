@@ -16,6 +16,10 @@ import (
 	"github.com/bmatcuk/doublestar"
 	"github.com/env0/terratag/cli"
 	"github.com/env0/terratag/internal/common"
+	"github.com/env0/terratag/internal/config"
+	"github.com/env0/terratag/internal/file"
+	"github.com/env0/terratag/internal/report"
+	"github.com/env0/terratag/internal/tfschema"
 	. "github.com/onsi/gomega"
 	"github.com/otiai10/copy"
 	"github.com/spf13/viper"
@@ -472,10 +476,15 @@ func filterSymlink(ss []string) (ret []string) {
 
 func TestToHclMap(t *testing.T) {
 	validCases := map[string]string{
-		`{"a":"b","c":"d"}`: `{"a"="b","c"="d"}`,
-		`a=b,c=d`:           `{"a"="b","c"="d"}`,
-		`a-key=b-value`:     `{"a-key"="b-value"}`,
-		"{}":                "{}",
+		`{"a":"b","c":"d"}`:                `{"a"="b","c"="d"}`,
+		`a=b,c=d`:                          `{"a"="b","c"="d"}`,
+		`a-key=b-value`:                    `{"a-key"="b-value"}`,
+		"{}":                               "{}",
+		`a=b c,d.e=f.g/h:i`:                `{"a"="b c","d.e"="f.g/h:i"}`,
+		`{"a":"b\"c"}`:                     `{"a"="b\"c"}`,
+		`{"a":"${b}"}`:                     `{"a"="$${b}"}`,
+		`a=expr:var.owner`:                 `{"a"=var.owner}`,
+		`{"a":"expr:timestamp()","b":"c"}`: `{"a"=timestamp(),"b"="c"}`,
 	}
 
 	for input, output := range validCases {
@@ -493,6 +502,7 @@ func TestToHclMap(t *testing.T) {
 		"_a=b",
 		"5a=b",
 		"a=b!",
+		`{"a":"expr:var."}`,
 	}
 
 	for i := range invalidCases {
@@ -504,6 +514,80 @@ func TestToHclMap(t *testing.T) {
 	}
 }
 
+func TestLoadTags(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"tags.json": `{"a": "json", "b": "json"}`,
+		"tags.yaml": "a: yaml\nb: yaml\n",
+		"tags.hcl":  "tags = {\n  a = \"hcl\"\n  b = \"hcl\"\n}\n",
+	}
+
+	for name, content := range files {
+		name, content := name, content
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+			tags, err := loadTags(cli.Args{TagsFile: path, Tags: "b=cli"})
+			require.NoError(t, err)
+
+			format := strings.TrimPrefix(filepath.Ext(name), ".")
+			assert.JSONEq(t, `{"a":"`+format+`","b":"cli"}`, tags)
+		})
+	}
+
+	t.Run("unsupported file", func(t *testing.T) {
+		path := filepath.Join(dir, "tags.txt")
+		require.NoError(t, os.WriteFile(path, []byte("a=b"), 0644))
+
+		_, err := loadTags(cli.Args{TagsFile: path})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetTagSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+resource_tags:
+  - name: gcp
+    type: ^(aws_|google_compute_)
+    tags:
+      team: aws
+  - name: gcp_rule1
+    type: ^aws_s3_
+    tags:
+      team: s3
+`), 0644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	args := &common.TaggingArgs{Tags: `{"Owner":"team"}`, Normalize: true, Config: cfg}
+
+	testCases := []struct {
+		provider     string
+		resourceType string
+		expectedSet  string
+		expectedTags string
+	}{
+		// The normalized tags get their own provider-specific set.
+		{"gcp", "google_storage_bucket", "gcp", `{"owner":"team"}`},
+		{"gcp", "google_compute_instance", "gcp__gcp", `{"owner":"team","team":"aws"}`},
+		{"aws", "aws_vpc", "aws__gcp", `{"Owner":"team","team":"aws"}`},
+		{"aws", "aws_s3_bucket", "aws__gcp__gcp_rule1", `{"Owner":"team","team":"s3"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.resourceType, func(t *testing.T) {
+			tagSet, tags, _, err := getTagSet(args, tc.provider, tc.resourceType)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSet, tagSet)
+			assert.JSONEq(t, tc.expectedTags, tags)
+		})
+	}
+}
+
 func TestEnvVariables(t *testing.T) {
 	os.Setenv("TERRATAG_TAGS", `{"a":"b"}`)
 	os.Setenv("TERRATAG_DIR", "./dir")
@@ -551,3 +635,233 @@ func TestEnvVariables(t *testing.T) {
 
 	assert.Equal(t, `{"c":"d"}`, args.Tags)
 }
+
+func TestTagFileResources_MergedExistingTags(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+
+	require.NoError(t, os.WriteFile(path, []byte(`resource "kubernetes_namespace" "labeled" {
+  metadata {
+    name   = "labeled"
+    labels = { app = "a" }
+  }
+}
+
+resource "kubernetes_namespace" "unlabeled" {
+  metadata {
+    name = "unlabeled"
+  }
+}
+`), 0644))
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/hashicorp/kubernetes": {"resource_schemas": {
+  "kubernetes_namespace": {"block": {"block_types": {"metadata": {"nesting_mode": "list", "block": {"attributes": {
+    "name": {"type": "string", "optional": true},
+    "labels": {"type": ["map", "string"], "optional": true}
+  }}}}}}
+}}}}`), 0644))
+
+	fileReport := &report.File{Path: path}
+
+	_, err := tagFileResources(path, &common.TaggingArgs{
+		Filter: ".*",
+		Dir:    dir,
+		Tags:   `{"owner":"team"}`,
+		Rename: true,
+	}, tfschema.NewStore(tfschema.StoreOptions{SchemaFile: schemaFile}), nil, fileReport)
+	require.NoError(t, err)
+
+	require.Len(t, fileReport.Resources, 2)
+	assert.Equal(t, "metadata.labels", fileReport.Resources[0].TagAttribute)
+	assert.True(t, fileReport.Resources[0].MergedExistingTags)
+	assert.False(t, fileReport.Resources[1].MergedExistingTags)
+}
+
+func TestTagFileResources_ProviderDefaultsAlias(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+
+	require.NoError(t, os.WriteFile(path, []byte(`provider "aws" {
+  alias = "west"
+}
+
+resource "aws_vpc" "default" {
+}
+
+resource "aws_vpc" "west" {
+  provider = aws.west
+}
+`), 0644))
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/hashicorp/aws": {"resource_schemas": {
+  "aws_vpc": {"block": {"attributes": {"tags": {"type": ["map", "string"], "optional": true}}}}
+}}}}`), 0644))
+
+	defaultTagsProviders := getDefaultTagsProviders([]string{path}, nil)
+	assert.Equal(t, map[string]bool{"aws.west": true}, defaultTagsProviders)
+
+	fileReport := &report.File{Path: path}
+
+	_, err := tagFileResources(path, &common.TaggingArgs{
+		Filter:               ".*",
+		Dir:                  dir,
+		Tags:                 `{"owner":"team"}`,
+		Rename:               true,
+		Strategy:             common.ProviderDefaultsStrategy,
+		DefaultTagsProviders: defaultTagsProviders,
+	}, tfschema.NewStore(tfschema.StoreOptions{SchemaFile: schemaFile}), nil, fileReport)
+	require.NoError(t, err)
+
+	require.Len(t, fileReport.Resources, 3)
+	assert.Equal(t, "aws.west", fileReport.Resources[0].Name)
+	assert.Equal(t, report.ResourceTagged, fileReport.Resources[0].Status)
+	// There's no default aws provider block to tag the resources of the default aws provider.
+	assert.Equal(t, "default", fileReport.Resources[1].Name)
+	assert.Equal(t, report.ResourceTagged, fileReport.Resources[1].Status)
+	assert.Empty(t, fileReport.Resources[1].SkipReason)
+	assert.Equal(t, "west", fileReport.Resources[2].Name)
+	assert.Equal(t, report.SkipReasonProviderDefaults, fileReport.Resources[2].SkipReason)
+}
+
+func TestTagFileResources_NestedBlocksVolumeTags(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+
+	require.NoError(t, os.WriteFile(path, []byte(`resource "aws_instance" "volume_tags" {
+  volume_tags = { app = "a" }
+
+  root_block_device {
+    volume_size = 8
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+
+resource "aws_instance" "block_tags" {
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+`), 0644))
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/hashicorp/aws": {"resource_schemas": {
+  "aws_instance": {"block": {
+    "attributes": {
+      "tags": {"type": ["map", "string"], "optional": true},
+      "volume_tags": {"type": ["map", "string"], "optional": true}
+    },
+    "block_types": {
+      "root_block_device": {"nesting_mode": "list", "block": {"attributes": {
+        "volume_size": {"type": "number", "optional": true},
+        "tags": {"type": ["map", "string"], "optional": true}
+      }}},
+      "ebs_block_device": {"nesting_mode": "set", "block": {"attributes": {
+        "device_name": {"type": "string", "required": true},
+        "tags": {"type": ["map", "string"], "optional": true}
+      }}}
+    }
+  }}
+}}}}`), 0644))
+
+	fileReport := &report.File{Path: path}
+
+	_, err := tagFileResources(path, &common.TaggingArgs{
+		Filter:          ".*",
+		Dir:             dir,
+		Tags:            `{"owner":"team"}`,
+		Rename:          true,
+		TagNestedBlocks: true,
+	}, tfschema.NewStore(tfschema.StoreOptions{SchemaFile: schemaFile}), nil, fileReport)
+	require.NoError(t, err)
+
+	require.Len(t, fileReport.Resources, 2)
+	// The AWS provider doesn't allow the block device tags along with volume_tags.
+	assert.Empty(t, fileReport.Resources[0].NestedTagPaths)
+	assert.Empty(t, fileReport.Resources[1].NestedTagPaths, "tagged by tagAwsInstance")
+
+	hcl, err := file.ReadHCLFile(filepath.Join(dir, "main.terratag.tf"))
+	require.NoError(t, err)
+
+	volumeTagsInstance := hcl.Body().Blocks()[0]
+	assert.NotNil(t, volumeTagsInstance.Body().GetAttribute("volume_tags"))
+
+	for _, block := range volumeTagsInstance.Body().Blocks() {
+		assert.Nil(t, block.Body().GetAttribute("tags"), block.Type())
+	}
+
+	for _, block := range hcl.Body().Blocks()[1].Body().Blocks() {
+		assert.NotNil(t, block.Body().GetAttribute("tags"), block.Type())
+	}
+}
+
+func TestTerratag_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, copy.Copy(filepath.Join(testsDir, "aws_tags_map", "input"), dir))
+
+	path := filepath.Join(dir, "main.tf")
+	src, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/hashicorp/aws": {"resource_schemas": {
+  "aws_s3_bucket": {"block": {"attributes": {"tags": {"type": ["map", "string"], "optional": true}}}}
+}}}}`), 0644))
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	os.Stdout = w
+
+	output := make(chan string)
+
+	go func() {
+		out, _ := io.ReadAll(r)
+		output <- string(out)
+	}()
+
+	err = Terratag(cli.Args{
+		// The fixtures tags.
+		Tags:                args[1][len("-tags="):],
+		Dir:                 dir,
+		Filter:              ".*",
+		Type:                string(common.Terraform),
+		Strategy:            string(common.ResourcesStrategy),
+		IsSkipTerratagFiles: true,
+		Rename:              true,
+		DryRun:              true,
+		SchemaFile:          schemaFile,
+		NoSchemaCache:       true,
+	})
+
+	w.Close()
+
+	os.Stdout = stdout
+
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(filepath.Join(testsDir, "aws_tags_map", "expected", "main.terratag.tf"))
+	require.NoError(t, err)
+
+	diff := <-output
+	assert.True(t, strings.HasPrefix(diff, "--- "+path+"\n+++ "+filepath.Join(dir, "main.terratag.tf")+"\n"), diff)
+
+	expectedDiff, err := file.GetTerratagFileDiff(path, string(expected), true)
+	require.NoError(t, err)
+	assert.Equal(t, expectedDiff, diff)
+
+	// No file is created or changed.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "main.tf", entries[0].Name())
+
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(src), string(actual))
+}
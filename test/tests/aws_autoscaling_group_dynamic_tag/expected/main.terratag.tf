@@ -0,0 +1,46 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+variable "tags" {
+  type = map(string)
+  default = {
+    Name = "terratag"
+  }
+}
+
+resource "aws_autoscaling_group" "dynamic_tag" {
+  name               = "terratag"
+  availability_zones = ["us-east-1a"]
+  max_size           = 1
+  min_size           = 1
+
+  launch_template {
+    id      = "lt-0123456789abcdef0"
+    version = "$Latest"
+  }
+
+  dynamic "tag" {
+    for_each = merge(var.tags, local.terratag_added_main)
+
+    content {
+      key                 = tag.key
+      value               = tag.value
+      propagate_at_launch = true
+    }
+  }
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
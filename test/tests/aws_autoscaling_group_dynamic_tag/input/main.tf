@@ -0,0 +1,41 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+variable "tags" {
+  type = map(string)
+  default = {
+    Name = "terratag"
+  }
+}
+
+resource "aws_autoscaling_group" "dynamic_tag" {
+  name               = "terratag"
+  availability_zones = ["us-east-1a"]
+  max_size           = 1
+  min_size           = 1
+
+  launch_template {
+    id      = "lt-0123456789abcdef0"
+    version = "$Latest"
+  }
+
+  dynamic "tag" {
+    for_each = var.tags
+
+    content {
+      key                 = tag.key
+      value               = tag.value
+      propagate_at_launch = true
+    }
+  }
+}
@@ -0,0 +1,37 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_ecs_service" "no_propagate_tags" {
+  name            = "terratag"
+  cluster         = "terratag"
+  task_definition = "terratag:1"
+  tags            = local.terratag_added_main
+  # Added by terratag
+  propagate_tags = "SERVICE"
+}
+
+resource "aws_ecs_service" "propagate_tags" {
+  name            = "terratag"
+  cluster         = "terratag"
+  task_definition = "terratag:1"
+  propagate_tags  = "TASK_DEFINITION"
+
+  tags = merge({
+    "Name" = "terratag"
+  }, local.terratag_added_main)
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
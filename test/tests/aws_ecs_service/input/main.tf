@@ -0,0 +1,29 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_ecs_service" "no_propagate_tags" {
+  name            = "terratag"
+  cluster         = "terratag"
+  task_definition = "terratag:1"
+}
+
+resource "aws_ecs_service" "propagate_tags" {
+  name            = "terratag"
+  cluster         = "terratag"
+  task_definition = "terratag:1"
+  propagate_tags  = "TASK_DEFINITION"
+
+  tags = {
+    Name = "terratag"
+  }
+}
@@ -0,0 +1,56 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_launch_template" "node_group" {
+  name_prefix = "terratag"
+  tags        = local.terratag_added_main
+  tag_specifications {
+    # Added by terratag
+    resource_type = "instance"
+    tags          = local.terratag_added_main
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "volume"
+    tags          = local.terratag_added_main
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "network-interface"
+    tags          = local.terratag_added_main
+  }
+}
+
+resource "aws_eks_node_group" "launch_template" {
+  cluster_name    = "terratag"
+  node_group_name = "terratag"
+  node_role_arn   = "arn:aws:iam::123456789012:role/terratag"
+  subnet_ids      = ["subnet-0123456789abcdef0"]
+
+  scaling_config {
+    desired_size = 1
+    max_size     = 1
+    min_size     = 1
+  }
+
+  launch_template {
+    id      = aws_launch_template.node_group.id
+    version = aws_launch_template.node_group.latest_version
+  }
+  tags = local.terratag_added_main
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
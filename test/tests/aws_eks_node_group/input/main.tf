@@ -0,0 +1,34 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_launch_template" "node_group" {
+  name_prefix = "terratag"
+}
+
+resource "aws_eks_node_group" "launch_template" {
+  cluster_name    = "terratag"
+  node_group_name = "terratag"
+  node_role_arn   = "arn:aws:iam::123456789012:role/terratag"
+  subnet_ids      = ["subnet-0123456789abcdef0"]
+
+  scaling_config {
+    desired_size = 1
+    max_size     = 1
+    min_size     = 1
+  }
+
+  launch_template {
+    id      = aws_launch_template.node_group.id
+    version = aws_launch_template.node_group.latest_version
+  }
+}
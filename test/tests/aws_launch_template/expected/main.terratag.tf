@@ -0,0 +1,69 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_launch_template" "no_tag_specifications" {
+  name_prefix   = "terratag"
+  instance_type = "t3.micro"
+  tags          = local.terratag_added_main
+  tag_specifications {
+    # Added by terratag
+    resource_type = "instance"
+    tags          = local.terratag_added_main
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "volume"
+    tags          = local.terratag_added_main
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "network-interface"
+    tags          = local.terratag_added_main
+  }
+}
+
+resource "aws_launch_template" "with_tag_specifications" {
+  name_prefix   = "terratag"
+  instance_type = "t3.micro"
+
+  tags = merge({
+    "Name" = "with_tag_specifications"
+  }, local.terratag_added_main)
+
+  tag_specifications {
+    resource_type = "instance"
+
+    tags = merge({
+      "Name" = "instance"
+    }, local.terratag_added_main)
+  }
+
+  dynamic "tag_specifications" {
+    for_each = ["volume"]
+
+    content {
+      resource_type = tag_specifications.value
+      tags          = local.terratag_added_main
+    }
+  }
+  tag_specifications {
+    # Added by terratag
+    resource_type = "network-interface"
+    tags          = local.terratag_added_main
+  }
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
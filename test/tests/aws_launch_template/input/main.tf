@@ -0,0 +1,42 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_launch_template" "no_tag_specifications" {
+  name_prefix   = "terratag"
+  instance_type = "t3.micro"
+}
+
+resource "aws_launch_template" "with_tag_specifications" {
+  name_prefix   = "terratag"
+  instance_type = "t3.micro"
+
+  tags = {
+    Name = "with_tag_specifications"
+  }
+
+  tag_specifications {
+    resource_type = "instance"
+
+    tags = {
+      Name = "instance"
+    }
+  }
+
+  dynamic "tag_specifications" {
+    for_each = ["volume"]
+
+    content {
+      resource_type = tag_specifications.value
+    }
+  }
+}
@@ -0,0 +1,57 @@
+terraform {
+  required_providers {
+    awscc = {
+      source  = "hashicorp/awscc"
+      version = "~> 1.0"
+    }
+  }
+}
+
+provider "awscc" {
+  region = "us-east-1"
+}
+
+resource "awscc_s3_bucket" "no_tags" {
+  bucket_name = "terratag-no-tags"
+  tags        = [for k, v in local.terratag_added_main : { key = k, value = v }]
+}
+
+resource "awscc_s3_bucket" "with_tags" {
+  bucket_name = "terratag-with-tags"
+
+  tags = concat([for tag in [
+    {
+      key   = "Name"
+      value = "My bucket"
+    }
+  ] : tag if !contains(keys(local.terratag_added_main), tag.key)], [for k, v in local.terratag_added_main : { key = k, value = v }])
+}
+
+resource "awscc_logs_log_group" "with_variable_tags" {
+  log_group_name = "terratag"
+
+  tags = concat([for tag in var.log_group_tags : tag if !contains(keys(local.terratag_added_main), tag.key)], [for k, v in local.terratag_added_main : { key = k, value = v }])
+}
+
+resource "awscc_ssm_parameter" "map_tags" {
+  name  = "terratag"
+  type  = "String"
+  value = "value"
+
+  tags = merge({
+    "Name" = "My parameter"
+  }, local.terratag_added_main)
+}
+
+variable "log_group_tags" {
+  type = list(object({
+    key   = string
+    value = string
+  }))
+  default = []
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
@@ -0,0 +1,51 @@
+terraform {
+  required_providers {
+    awscc = {
+      source  = "hashicorp/awscc"
+      version = "~> 1.0"
+    }
+  }
+}
+
+provider "awscc" {
+  region = "us-east-1"
+}
+
+resource "awscc_s3_bucket" "no_tags" {
+  bucket_name = "terratag-no-tags"
+}
+
+resource "awscc_s3_bucket" "with_tags" {
+  bucket_name = "terratag-with-tags"
+
+  tags = [
+    {
+      key   = "Name"
+      value = "My bucket"
+    }
+  ]
+}
+
+resource "awscc_logs_log_group" "with_variable_tags" {
+  log_group_name = "terratag"
+
+  tags = var.log_group_tags
+}
+
+resource "awscc_ssm_parameter" "map_tags" {
+  name  = "terratag"
+  type  = "String"
+  value = "value"
+
+  tags = {
+    Name = "My parameter"
+  }
+}
+
+variable "log_group_tags" {
+  type = list(object({
+    key   = string
+    value = string
+  }))
+  default = []
+}
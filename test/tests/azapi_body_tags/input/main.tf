@@ -0,0 +1,81 @@
+terraform {
+  required_providers {
+    azapi = {
+      source = "Azure/azapi"
+    }
+  }
+}
+
+provider "azapi" {
+}
+
+variable "resource_group_id" {
+  type    = string
+  default = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example"
+}
+
+variable "registry_type" {
+  type    = string
+  default = "Microsoft.ContainerRegistry/registries@2020-11-01-preview"
+}
+
+variable "unknown_type" {
+  type = string
+}
+
+locals {
+  storage_account_type = "Microsoft.Storage/storageAccounts@2023-01-01"
+}
+
+resource "azapi_resource" "object_body_tags" {
+  type      = var.registry_type
+  name      = "registry1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    sku = {
+      name = "Standard"
+    }
+    tags = {
+      Key = "Value"
+    }
+  }
+}
+
+resource "azapi_resource" "jsonencode_body_tags" {
+  type      = local.storage_account_type
+  name      = "storage1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = jsonencode({
+    kind = "StorageV2"
+    sku = {
+      name = "Standard_LRS"
+    }
+    tags = {
+      Key = "Value"
+    }
+  })
+}
+
+resource "azapi_resource" "no_body_tags" {
+  type      = local.storage_account_type
+  name      = "storage2"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    kind = "StorageV2"
+    sku = {
+      name = "Standard_LRS"
+    }
+  }
+}
+
+resource "azapi_resource" "unknown_type" {
+  type      = var.unknown_type
+  name      = "unknown"
+  parent_id = var.resource_group_id
+}
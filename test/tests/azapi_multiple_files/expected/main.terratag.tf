@@ -0,0 +1,43 @@
+terraform {
+  required_providers {
+    azapi = {
+      source = "Azure/azapi"
+    }
+  }
+}
+
+provider "azapi" {
+}
+
+variable "resource_group_id" {
+  type    = string
+  default = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example"
+}
+
+variable "registry_type" {
+  type    = string
+  default = "Microsoft.ContainerRegistry/registries@2020-11-01-preview"
+}
+
+locals {
+  storage_account_type = "Microsoft.Storage/storageAccounts@2023-01-01"
+}
+
+resource "azapi_resource" "registry" {
+  type      = var.registry_type
+  name      = "registry1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    sku = {
+      name = "Standard"
+    }
+  }
+  tags = local.terratag_added_main
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
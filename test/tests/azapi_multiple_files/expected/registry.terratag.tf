@@ -0,0 +1,20 @@
+resource "azapi_resource" "registry2" {
+  type      = var.registry_type
+  name      = "registry2"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    sku = {
+      name = "Standard"
+    }
+    tags = merge({
+      Key = "Value"
+    }, local.terratag_added_registry)
+  }
+}
+
+locals {
+  terratag_added_registry = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
@@ -0,0 +1,19 @@
+resource "azapi_resource" "storage" {
+  type      = local.storage_account_type
+  name      = "storage1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    kind = "StorageV2"
+    sku = {
+      name = "Standard_LRS"
+    }
+  }
+  tags = local.terratag_added_storage
+}
+
+locals {
+  terratag_added_storage = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
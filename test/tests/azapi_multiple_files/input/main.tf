@@ -0,0 +1,37 @@
+terraform {
+  required_providers {
+    azapi = {
+      source = "Azure/azapi"
+    }
+  }
+}
+
+provider "azapi" {
+}
+
+variable "resource_group_id" {
+  type    = string
+  default = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example"
+}
+
+variable "registry_type" {
+  type    = string
+  default = "Microsoft.ContainerRegistry/registries@2020-11-01-preview"
+}
+
+locals {
+  storage_account_type = "Microsoft.Storage/storageAccounts@2023-01-01"
+}
+
+resource "azapi_resource" "registry" {
+  type      = var.registry_type
+  name      = "registry1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    sku = {
+      name = "Standard"
+    }
+  }
+}
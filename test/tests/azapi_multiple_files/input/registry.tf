@@ -0,0 +1,15 @@
+resource "azapi_resource" "registry2" {
+  type      = var.registry_type
+  name      = "registry2"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    sku = {
+      name = "Standard"
+    }
+    tags = {
+      Key = "Value"
+    }
+  }
+}
@@ -0,0 +1,13 @@
+resource "azapi_resource" "storage" {
+  type      = local.storage_account_type
+  name      = "storage1"
+  parent_id = var.resource_group_id
+  location  = "westeurope"
+
+  body = {
+    kind = "StorageV2"
+    sku = {
+      name = "Standard_LRS"
+    }
+  }
+}
@@ -0,0 +1,88 @@
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "google_compute_instance" "boot_disk_image" {
+  name         = "terratag"
+  machine_type = "e2-micro"
+  zone         = "us-central1-a"
+
+  boot_disk {
+    initialize_params {
+      image  = "debian-cloud/debian-12"
+      labels = local.terratag_added_main
+    }
+  }
+
+  network_interface {
+    network = "default"
+  }
+  labels = local.terratag_added_main
+}
+
+resource "google_compute_instance" "boot_disk_source" {
+  name         = "terratag"
+  machine_type = "e2-micro"
+  zone         = "us-central1-a"
+
+  labels = merge({
+    "app" = "terratag"
+  }, local.terratag_added_main)
+
+  boot_disk {
+    source = "terratag-disk"
+  }
+
+  network_interface {
+    network = "default"
+  }
+}
+
+resource "google_compute_instance_template" "disks" {
+  name_prefix  = "terratag"
+  machine_type = "e2-micro"
+
+  disk {
+    source_image = "debian-cloud/debian-12"
+    boot         = true
+
+    labels = merge({
+      "disk" = "boot"
+    }, local.terratag_added_main)
+  }
+
+  disk {
+    source = "terratag-disk"
+  }
+
+  network_interface {
+    network = "default"
+  }
+  labels = local.terratag_added_main
+}
+
+resource "google_compute_region_instance_template" "disks" {
+  name_prefix  = "terratag"
+  machine_type = "e2-micro"
+  region       = "us-central1"
+
+  disk {
+    source_image = "debian-cloud/debian-12"
+    labels       = local.terratag_added_main
+  }
+
+  network_interface {
+    network = "default"
+  }
+  labels = local.terratag_added_main
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
@@ -0,0 +1,78 @@
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "google_compute_instance" "boot_disk_image" {
+  name         = "terratag"
+  machine_type = "e2-micro"
+  zone         = "us-central1-a"
+
+  boot_disk {
+    initialize_params {
+      image = "debian-cloud/debian-12"
+    }
+  }
+
+  network_interface {
+    network = "default"
+  }
+}
+
+resource "google_compute_instance" "boot_disk_source" {
+  name         = "terratag"
+  machine_type = "e2-micro"
+  zone         = "us-central1-a"
+
+  labels = {
+    app = "terratag"
+  }
+
+  boot_disk {
+    source = "terratag-disk"
+  }
+
+  network_interface {
+    network = "default"
+  }
+}
+
+resource "google_compute_instance_template" "disks" {
+  name_prefix  = "terratag"
+  machine_type = "e2-micro"
+
+  disk {
+    source_image = "debian-cloud/debian-12"
+    boot         = true
+
+    labels = {
+      disk = "boot"
+    }
+  }
+
+  disk {
+    source = "terratag-disk"
+  }
+
+  network_interface {
+    network = "default"
+  }
+}
+
+resource "google_compute_region_instance_template" "disks" {
+  name_prefix  = "terratag"
+  machine_type = "e2-micro"
+  region       = "us-central1"
+
+  disk {
+    source_image = "debian-cloud/debian-12"
+  }
+
+  network_interface {
+    network = "default"
+  }
+}
@@ -0,0 +1,43 @@
+terraform {
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "google_container_node_pool" "no-labels-pool" {
+  cluster = "cluster"
+
+  node_config {
+    machine_type    = "n1-standard-1"
+    resource_labels = local.terratag_added_main
+    labels          = local.terratag_added_main
+  }
+}
+
+resource "google_container_node_pool" "existing-labels-pool" {
+  cluster = "cluster"
+
+  node_config {
+    machine_type = "n1-standard-1"
+    labels = merge({
+      "foo" = "bar"
+    }, local.terratag_added_main)
+    resource_labels = local.terratag_added_main
+  }
+}
+
+resource "google_container_node_pool" "no-node-config-pool" {
+  cluster = "cluster"
+  node_config {
+    resource_labels = local.terratag_added_main
+    labels          = local.terratag_added_main
+  }
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
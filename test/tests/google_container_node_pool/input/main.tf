@@ -0,0 +1,31 @@
+terraform {
+  required_providers {
+    google = {
+      source = "hashicorp/google"
+      version = "~> 5.0"
+    }
+  }
+}
+
+resource "google_container_node_pool" "no-labels-pool" {
+  cluster = "cluster"
+
+  node_config {
+    machine_type = "n1-standard-1"
+  }
+}
+
+resource "google_container_node_pool" "existing-labels-pool" {
+  cluster = "cluster"
+
+  node_config {
+    machine_type = "n1-standard-1"
+    labels = {
+      foo = "bar"
+    }
+  }
+}
+
+resource "google_container_node_pool" "no-node-config-pool" {
+  cluster = "cluster"
+}
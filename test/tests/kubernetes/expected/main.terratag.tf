@@ -0,0 +1,70 @@
+terraform {
+  required_providers {
+    kubernetes = {
+      source  = "hashicorp/kubernetes"
+      version = "~> 2.0"
+    }
+  }
+}
+
+provider "kubernetes" {
+  config_path = "~/.kube/config"
+}
+
+resource "kubernetes_namespace" "no_labels" {
+  metadata {
+    name   = "terratag"
+    labels = local.terratag_added_main
+  }
+}
+
+resource "kubernetes_config_map" "with_labels" {
+  metadata {
+    name      = "terratag"
+    namespace = kubernetes_namespace.no_labels.metadata[0].name
+
+    labels = merge({
+      "app" = "terratag"
+    }, local.terratag_added_main)
+  }
+
+  data = {
+    key = "value"
+  }
+}
+
+resource "kubernetes_deployment" "workload" {
+  metadata {
+    name      = "terratag"
+    namespace = kubernetes_namespace.no_labels.metadata[0].name
+    labels    = local.terratag_added_main
+  }
+
+  spec {
+    selector {
+      match_labels = {
+        app = "terratag"
+      }
+    }
+
+    template {
+      metadata {
+        labels = {
+          app = "terratag"
+        }
+      }
+
+      spec {
+        container {
+          name  = "terratag"
+          image = "nginx:1.27"
+        }
+      }
+    }
+  }
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
@@ -0,0 +1,63 @@
+terraform {
+  required_providers {
+    kubernetes = {
+      source  = "hashicorp/kubernetes"
+      version = "~> 2.0"
+    }
+  }
+}
+
+provider "kubernetes" {
+  config_path = "~/.kube/config"
+}
+
+resource "kubernetes_namespace" "no_labels" {
+  metadata {
+    name = "terratag"
+  }
+}
+
+resource "kubernetes_config_map" "with_labels" {
+  metadata {
+    name      = "terratag"
+    namespace = kubernetes_namespace.no_labels.metadata[0].name
+
+    labels = {
+      app = "terratag"
+    }
+  }
+
+  data = {
+    key = "value"
+  }
+}
+
+resource "kubernetes_deployment" "workload" {
+  metadata {
+    name      = "terratag"
+    namespace = kubernetes_namespace.no_labels.metadata[0].name
+  }
+
+  spec {
+    selector {
+      match_labels = {
+        app = "terratag"
+      }
+    }
+
+    template {
+      metadata {
+        labels = {
+          app = "terratag"
+        }
+      }
+
+      spec {
+        container {
+          name  = "terratag"
+          image = "nginx:1.27"
+        }
+      }
+    }
+  }
+}
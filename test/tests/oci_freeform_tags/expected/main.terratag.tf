@@ -0,0 +1,38 @@
+terraform {
+  required_providers {
+    oci = {
+      source  = "oracle/oci"
+      version = "~> 6.0"
+    }
+  }
+}
+
+variable "compartment_id" {
+  type    = string
+  default = "ocid1.compartment.oc1..example"
+}
+
+resource "oci_core_vcn" "vcn" {
+  compartment_id = var.compartment_id
+  cidr_block     = "10.0.0.0/16"
+  freeform_tags  = local.terratag_added_main
+}
+
+resource "oci_objectstorage_bucket" "bucket" {
+  compartment_id = var.compartment_id
+  name           = "terratag"
+  namespace      = "terratag"
+
+  freeform_tags = merge({
+    "Name" = "My bucket"
+  }, local.terratag_added_main)
+
+  defined_tags = {
+    "Operations.CostCenter" = "42"
+  }
+}
+
+locals {
+  terratag_added_main = {"env0_environment_id"="40907eff-cf7c-419a-8694-e1c6bf1d1168","env0_project_id"="43fd4ff1-8d37-4d9d-ac97-295bd850bf94"}
+}
+
@@ -0,0 +1,32 @@
+terraform {
+  required_providers {
+    oci = {
+      source  = "oracle/oci"
+      version = "~> 6.0"
+    }
+  }
+}
+
+variable "compartment_id" {
+  type    = string
+  default = "ocid1.compartment.oc1..example"
+}
+
+resource "oci_core_vcn" "vcn" {
+  compartment_id = var.compartment_id
+  cidr_block     = "10.0.0.0/16"
+}
+
+resource "oci_objectstorage_bucket" "bucket" {
+  compartment_id = var.compartment_id
+  name           = "terratag"
+  namespace      = "terratag"
+
+  freeform_tags = {
+    Name = "My bucket"
+  }
+
+  defined_tags = {
+    "Operations.CostCenter" = "42"
+  }
+}